@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mbreese/tabl/textfile"
+	"github.com/spf13/cobra"
+)
+
+var catIntersect bool
+var catReport bool
+
+func init() {
+	catCmd.Flags().BoolVarP(&ShowComments, "show-comments", "H", false, "Show comments (from the first file)")
+	catCmd.Flags().BoolVar(&IsCSV, "csv", false, "The files are CSV files")
+	catCmd.Flags().BoolVar(&HeaderComment, "header-comment", false, "The header is the last commented line")
+	catCmd.Flags().BoolVar(&NoHeader, "no-header", false, "Files have no header")
+	catCmd.Flags().BoolVar(&UnionHeader, "union", false, "Use all columns from all files (missing values are blank)")
+	catCmd.Flags().BoolVar(&catIntersect, "intersect", false, "Only use the columns that are in all files")
+	catCmd.Flags().StringVar(&SourceCol, "source-col", "", "Add a column with this name with the source filename for each row")
+	catCmd.Flags().BoolVar(&catReport, "report", false, "Write which file introduced (or dropped) each column to stderr")
+	rootCmd.AddCommand(catCmd)
+}
+
+var catCmd = &cobra.Command{
+	Use:   "cat [file...]",
+	Short: "Concatenate tabular files (with one header)",
+	Long: `Concatenate tabular files (with one header).
+
+The header is only written once. If the files have the same columns in a
+different order, the columns are re-ordered (by name) to match the first file.
+Files with different columns can be combined with --union (all columns, missing
+values are blank) or --intersect (only the columns in all files).
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if UnionHeader && catIntersect {
+			return errors.New("Only one of --union or --intersect can be used")
+		}
+		return checkFiles(args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		mf := textfile.NewMultiFile(newFiles(args)).
+			WithReorderColumns(true).
+			WithUnionHeader(UnionHeader).
+			WithIntersectHeader(catIntersect).
+			WithSourceColumn(SourceCol)
+
+		txt, err := mf.Open()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		if catReport {
+			mf.WriteHeaderReport(os.Stderr)
+		}
+
+		err = textfile.NewTextCat(txt).
+			WithShowComments(ShowComments).
+			WriteFile(os.Stdout)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
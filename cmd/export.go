@@ -19,13 +19,15 @@ func init() {
 	exportCmd.Flags().BoolVar(&IsCSV, "csv", false, "The file is a CSV file")
 	exportCmd.Flags().BoolVar(&HeaderComment, "header-comment", false, "The header is the last commented line")
 	exportCmd.Flags().BoolVar(&NoHeader, "no-header", false, "File has no header")
+	exportCmd.Flags().BoolVar(&UnionHeader, "union", false, "Combine files with different headers (union of the columns, by name)")
+	exportCmd.Flags().StringVar(&SourceCol, "source-col", "", "Add a column with this name with the source filename for each row")
 	// exportCmd.Flags().StringArrayVarP(&ExportCols, "key", "k", nil, "Columns to export (comma separated, names or indexes, requried)")
 
 	rootCmd.AddCommand(exportCmd)
 }
 
 var exportCmd = &cobra.Command{
-	Use:   "export [cols] [file]",
+	Use:   "export [cols] [file...]",
 	Short: "Extract columns from a tabular file",
 	Long: `Extract columns from a tabular file.
 
@@ -49,24 +51,13 @@ Example column values:
 			return fmt.Errorf("Missing [cols]")
 		}
 
-		if len(args) > 1 && args[1] != "-" {
-			_, err := os.Stat(args[1])
-			if os.IsNotExist(err) {
-				return fmt.Errorf("Missing file: %s", args[1])
-			}
-		}
-
-		return nil
+		return checkFiles(args[1:])
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 1 {
-			args = []string{args[0], "-"}
-		}
-		var txt *textfile.DelimitedTextFile
-		if !IsCSV {
-			txt = textfile.NewTabFile(args[1])
-		} else {
-			txt = textfile.NewCSVFile(args[1])
+		txt, err := openFiles(args[1:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 
 		cols, err := ParseColumnList(args[0])
@@ -74,9 +65,6 @@ Example column values:
 			panic(err)
 		}
 
-		// by default we won't process headers as special in the "view" mode
-		txt = txt.WithNoHeader(NoHeader).WithHeaderComment(HeaderComment)
-
 		err = textfile.NewTextExporter(txt, cols).
 			WithShowComments(ShowComments).
 			WriteFile(os.Stdout)
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mbreese/tabl/textfile"
+)
+
+// UnionHeader -- combine files with different headers using the union of the columns
+var UnionHeader bool
+
+// SourceCol -- add a column with the source filename
+var SourceCol string
+
+// expandFiles will expand any glob patterns in the list of files. Patterns
+// that don't match any files are returned as-is.
+func expandFiles(args []string) []string {
+	var fnames []string
+	for _, arg := range args {
+		if arg != "-" && strings.ContainsAny(arg, "*?[") {
+			matches, err := filepath.Glob(arg)
+			if err == nil && len(matches) > 0 {
+				fnames = append(fnames, matches...)
+				continue
+			}
+		}
+		fnames = append(fnames, arg)
+	}
+	return fnames
+}
+
+// checkFiles makes sure that all of the files (after glob expansion) exist
+func checkFiles(args []string) error {
+	fnames := expandFiles(args)
+	for _, fname := range fnames {
+		if fname == "-" {
+			if len(fnames) > 1 {
+				return fmt.Errorf("stdin (-) can't be combined with other files")
+			}
+			continue
+		}
+		_, err := os.Stat(fname)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("Missing file: %s", fname)
+		}
+	}
+	return nil
+}
+
+// newFiles returns a delimited text file for each file (after glob expansion)
+func newFiles(args []string) []*textfile.DelimitedTextFile {
+	fnames := expandFiles(args)
+	if len(fnames) == 0 {
+		fnames = []string{"-"}
+	}
+
+	files := make([]*textfile.DelimitedTextFile, len(fnames))
+	for i, fname := range fnames {
+		if !IsCSV {
+			files[i] = textfile.NewTabFile(fname)
+		} else {
+			files[i] = textfile.NewCSVFile(fname)
+		}
+		files[i] = files[i].WithNoHeader(NoHeader).WithHeaderComment(HeaderComment)
+	}
+	return files
+}
+
+// openFiles opens the files as a single delimited text file. If there is more than
+// one file, they are read in order as one table (with one header).
+func openFiles(args []string) (*textfile.DelimitedTextFile, error) {
+	files := newFiles(args)
+
+	if len(files) == 1 && SourceCol == "" {
+		return files[0], nil
+	}
+
+	return textfile.NewMultiFile(files).
+		WithUnionHeader(UnionHeader).
+		WithSourceColumn(SourceCol).
+		Open()
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var copyCmd string
+
 func init() {
 	// lessCmd.Flags().BoolVarP(&ShowLineNum, "show-linenum", "L", false, "Show line number")
 	lessCmd.Flags().BoolVar(&NoHeader, "no-header", false, "File has no header")
@@ -15,39 +17,30 @@ func init() {
 	lessCmd.Flags().BoolVar(&IsCSV, "csv", false, "The file is a CSV file")
 	lessCmd.Flags().IntVar(&MinWidth, "min", 0, "Minimum column width")
 	lessCmd.Flags().IntVar(&MaxWidth, "max", 0, "Maximum column width")
+	lessCmd.Flags().BoolVar(&UnionHeader, "union", false, "Combine files with different headers (union of the columns, by name)")
+	lessCmd.Flags().StringVar(&SourceCol, "source-col", "", "Add a column with this name with the source filename for each row")
+	lessCmd.Flags().StringVar(&copyCmd, "copy-cmd", os.Getenv("TABL_COPY_CMD"), "Command to copy text to the clipboard if OSC 52 escape codes can't be written to the terminal, like \"xclip -selection clipboard\" ($TABL_COPY_CMD)")
 	rootCmd.AddCommand(lessCmd)
 }
 
 var lessCmd = &cobra.Command{
-	Use:   "less",
+	Use:   "less [file...]",
 	Short: "Page through a tabular file",
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) > 0 && args[0] != "-" {
-			_, err := os.Stat(args[0])
-			if os.IsNotExist(err) {
-				return fmt.Errorf("Missing file: %s", args[0])
-			}
-		}
-		return nil
+		return checkFiles(args)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
-			args = []string{"-"}
+		txt, err := openFiles(args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
-		var txt *textfile.DelimitedTextFile
-		if !IsCSV {
-			txt = textfile.NewTabFile(args[0])
-		} else {
-			txt = textfile.NewCSVFile(args[0])
-		}
-
-		txt = txt.WithNoHeader(NoHeader).
-			WithHeaderComment(HeaderComment)
 
 		textfile.NewTextPager(txt).
 			WithShowLineNum(ShowLineNum).
 			WithMaxWidth(MaxWidth).
 			WithMinWidth(MinWidth).
+			WithCopyCommand(copyCmd).
 			Show()
 	},
 }
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mbreese/tabl/textfile"
+	"github.com/spf13/cobra"
+)
+
+var mergeCols MultiColumnVar
+
+var mergeChromOrder string
+var mergeUnique bool
+
+func init() {
+	mergeCmd.Flags().BoolVarP(&ShowComments, "show-comments", "H", false, "Show comments (from the first file)")
+	mergeCmd.Flags().BoolVar(&IsCSV, "csv", false, "The files are CSV files")
+	mergeCmd.Flags().BoolVar(&HeaderComment, "header-comment", false, "The header is the last commented line")
+	mergeCmd.Flags().BoolVar(&NoHeader, "no-header", false, "Files have no header")
+	mergeCmd.Flags().VarP(&mergeCols, "key", "k", "Columns the files are sorted by (multiple allowed, comma separated, end with ':n' for numeric sort, ':r' for reverse sort, ':c' for chromosome sort)")
+	mergeCmd.Flags().StringVar(&mergeChromOrder, "chrom-order", "", "File with the chromosome order for ':c' columns (first column, ex: .fai, .genome)")
+	mergeCmd.Flags().BoolVarP(&mergeUnique, "unique", "u", false, "Only output the first row for each key")
+
+	rootCmd.AddCommand(mergeCmd)
+}
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge file1 file2...",
+	Short: "Merge files that are already sorted by the same columns",
+	Long: `Merge files that are already sorted by the same columns.
+
+All of the files must have the same header and be sorted by the same
+--key columns (as with "tabl sort"). The merged output has a single header.
+Rows with equal keys are written in the order the files were given.
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(mergeCols.Values) == 0 {
+			return errors.New("Missing value for --key (at least one column to merge by is required)")
+		}
+		if len(args) == 0 {
+			return errors.New("Missing files to merge")
+		}
+		for _, arg := range args {
+			if arg != "-" {
+				_, err := os.Stat(arg)
+				if os.IsNotExist(err) {
+					return fmt.Errorf("Missing file: %s", arg)
+				}
+			}
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := mergeCols.WithChromOrder(mergeChromOrder); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		files := make([]*textfile.DelimitedTextFile, len(args))
+		for i, arg := range args {
+			if !IsCSV {
+				files[i] = textfile.NewTabFile(arg)
+			} else {
+				files[i] = textfile.NewCSVFile(arg)
+			}
+			files[i] = files[i].WithNoHeader(NoHeader).WithHeaderComment(HeaderComment)
+		}
+
+		err := textfile.NewTextMerger(files, mergeCols.Values).
+			WithShowComments(ShowComments).
+			WithUnique(mergeUnique).
+			WriteFile(os.Stdout)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/mbreese/tabl/textfile"
 )
@@ -23,35 +24,29 @@ func (mv *MultiColumnVar) Set(s string) error {
 	var newcols []*textfile.TextColumn
 	var err error
 
-	if len(s) > 2 && s[len(s)-2:] == ":n" {
-		newcols, err = ParseColumnList(s[0 : len(s)-2])
-		if err != nil {
-			return err
-		}
-		for i, v := range newcols {
-			newcols[i] = v.AsNumber()
-		}
-	} else if len(s) > 2 && s[len(s)-2:] == ":r" {
-		newcols, err = ParseColumnList(s[0 : len(s)-2])
-		if err != nil {
-			return err
-		}
-		for i, v := range newcols {
-			newcols[i] = v.AsReverse()
-		}
-	} else if len(s) > 3 && (s[len(s)-3:] == ":rn" || s[len(s)-3:] == ":nr") {
-		newcols, err = ParseColumnList(s[0 : len(s)-3])
-		if err != nil {
-			return err
+	// sort modifiers: 'n' numeric, 'r' reverse, 'c' chromosome (ex: col1:n, chrom:c, 3:rn)
+	mods := ""
+	if idx := strings.LastIndex(s, ":"); idx > 0 && idx < len(s)-1 && strings.Trim(s[idx+1:], "nrc") == "" {
+		mods = s[idx+1:]
+		s = s[:idx]
+	}
+
+	newcols, err = ParseColumnList(s)
+	if err != nil {
+		return err
+	}
+
+	for i, v := range newcols {
+		if strings.ContainsRune(mods, 'n') {
+			v = v.AsNumber()
 		}
-		for i, v := range newcols {
-			newcols[i] = v.AsReverse().AsNumber()
+		if strings.ContainsRune(mods, 'r') {
+			v = v.AsReverse()
 		}
-	} else {
-		newcols, err = ParseColumnList(s)
-		if err != nil {
-			return err
+		if strings.ContainsRune(mods, 'c') {
+			v = v.AsChrom()
 		}
+		newcols[i] = v
 	}
 
 	mv.Values = append(mv.Values, newcols...)
@@ -63,3 +58,18 @@ func (mv *MultiColumnVar) Set(s string) error {
 func (mv *MultiColumnVar) Type() string {
 	return "cols"
 }
+
+// WithChromOrder - set the chromosome ordering (from a .fai/.genome file) for all of the columns
+func (mv *MultiColumnVar) WithChromOrder(fname string) error {
+	if fname == "" {
+		return nil
+	}
+	order, err := textfile.LoadChromOrder(fname)
+	if err != nil {
+		return err
+	}
+	for _, col := range mv.Values {
+		col.WithChromOrder(order)
+	}
+	return nil
+}
@@ -11,12 +11,23 @@ import (
 
 var sortCols MultiColumnVar
 
+var sortChromOrder string
+var sortStable bool
+var sortUnique bool
+var sortCheck bool
+
 func init() {
 	sortCmd.Flags().BoolVarP(&ShowComments, "show-comments", "H", false, "Show comments")
 	sortCmd.Flags().BoolVar(&IsCSV, "csv", false, "The file is a CSV file")
 	sortCmd.Flags().BoolVar(&HeaderComment, "header-comment", false, "The header is the last commented line")
 	sortCmd.Flags().BoolVar(&NoHeader, "no-header", false, "File has no header")
-	sortCmd.Flags().VarP(&sortCols, "key", "k", "Columns to sort by (multiple allowed, comma separated, end with ':n' for numeric sort, ':r' for reverse sort)")
+	sortCmd.Flags().VarP(&sortCols, "key", "k", "Columns to sort by (multiple allowed, comma separated, end with ':n' for numeric sort, ':r' for reverse sort, ':c' for chromosome sort)")
+	sortCmd.Flags().StringVar(&sortChromOrder, "chrom-order", "", "File with the chromosome order for ':c' columns (first column, ex: .fai, .genome)")
+	sortCmd.Flags().BoolVarP(&sortStable, "stable", "s", false, "Stable sort (rows with equal keys keep their input order)")
+	sortCmd.Flags().BoolVarP(&sortUnique, "unique", "u", false, "Only output the first row for each key")
+	sortCmd.Flags().BoolVarP(&sortCheck, "check", "c", false, "Check that the file is already sorted (no output is written)")
+	sortCmd.Flags().BoolVar(&UnionHeader, "union", false, "Combine files with different headers (union of the columns, by name)")
+	sortCmd.Flags().StringVar(&SourceCol, "source-col", "", "Add a column with this name with the source filename for each row")
 	// exportCmd.Flags().StringVar(&ExportCols, "cols", "", "Columns to export (comma separated, names or indexes, requried)")
 
 	// sortCmd.MarkFlagRequired("key")
@@ -25,21 +36,14 @@ func init() {
 }
 
 var sortCmd = &cobra.Command{
-	Use:   "sort [file]",
+	Use:   "sort [file...]",
 	Short: "Sort a file by columns",
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(sortCols.Values) == 0 {
 			// TODO: make the default sort by all columns in text mode
 			return errors.New("Missing value for --key (at least one column to sort by is required)")
 		}
-		if len(args) > 0 && args[0] != "-" {
-			_, err := os.Stat(args[0])
-			if os.IsNotExist(err) {
-				return fmt.Errorf("Missing file: %s", args[0])
-			}
-		}
-
-		return nil
+		return checkFiles(args)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(sortCols.Values) == 0 {
@@ -48,22 +52,32 @@ var sortCmd = &cobra.Command{
 			return
 		}
 
-		if len(args) == 0 {
-			args = []string{"-"}
-		}
-		var txt *textfile.DelimitedTextFile
-		if !IsCSV {
-			txt = textfile.NewTabFile(args[0])
-		} else {
-			txt = textfile.NewCSVFile(args[0])
+		if err := sortCols.WithChromOrder(sortChromOrder); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 
-		// by default we won't process headers as special in the "view" mode
-		txt = txt.WithNoHeader(NoHeader).WithHeaderComment(HeaderComment)
+		txt, err := openFiles(args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 
-		err := textfile.NewTextSorter(txt, sortCols.Values).
+		sorter := textfile.NewTextSorter(txt, sortCols.Values).
 			WithShowComments(ShowComments).
-			WriteFile(os.Stdout)
+			WithStable(sortStable).
+			WithUnique(sortUnique)
+
+		if sortCheck {
+			err = sorter.Check()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		err = sorter.WriteFile(os.Stdout)
 
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -1,13 +1,36 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 
+	"github.com/mbreese/tabl/support"
 	"github.com/mbreese/tabl/textfile"
 	"github.com/spf13/cobra"
 )
 
+var viewDecimals int
+var viewSigDigits int
+var viewThousands bool
+var viewSciAbove float64
+var viewSciBelow float64
+var viewStyle string
+var viewWrap bool
+var viewWrapWords bool
+var viewMaxLines int
+var viewFit string
+var viewWidth int
+var viewVertical bool
+var viewColor string
+var viewZebra bool
+var viewHighlight string
+var viewExactWidths bool
+var viewRepeatHeader int
+var viewSummary bool
+
 func init() {
 	viewCmd.Flags().BoolVarP(&ShowComments, "show-comments", "H", false, "Show comments")
 	viewCmd.Flags().BoolVarP(&ShowLineNum, "show-linenum", "L", false, "Show line number")
@@ -16,40 +39,150 @@ func init() {
 	viewCmd.Flags().BoolVar(&NoHeader, "no-header", false, "File has no header")
 	viewCmd.Flags().IntVar(&MinWidth, "min", 0, "Minimum column width")
 	viewCmd.Flags().IntVar(&MaxWidth, "max", 0, "Maximum column width")
+	viewCmd.Flags().BoolVar(&UnionHeader, "union", false, "Combine files with different headers (union of the columns, by name)")
+	viewCmd.Flags().StringVar(&SourceCol, "source-col", "", "Add a column with this name with the source filename for each row")
+	viewCmd.Flags().IntVar(&viewDecimals, "decimals", -1, "Show numbers with this many decimal places")
+	viewCmd.Flags().IntVar(&viewSigDigits, "sig-digits", 0, "Show numbers rounded to this many significant digits")
+	viewCmd.Flags().BoolVar(&viewThousands, "thousands", false, "Show numbers with a thousands separator")
+	viewCmd.Flags().Float64Var(&viewSciAbove, "sci-above", 0, "Show numbers at or above this (absolute) value in scientific notation")
+	viewCmd.Flags().Float64Var(&viewSciBelow, "sci-below", 0, "Show non-zero numbers below this (absolute) value in scientific notation")
+	viewCmd.Flags().StringVar(&viewStyle, "style", "plain", "Table style ("+strings.Join(textfile.TableStyleNames, ", ")+")")
+	viewCmd.Flags().BoolVar(&viewWrap, "wrap", false, "Wrap values wider than the column onto more lines (instead of truncating; without --max, the table is fit to the terminal width)")
+	viewCmd.Flags().BoolVar(&viewWrapWords, "wrap-words", false, "Wrap values at word boundaries (implies --wrap)")
+	viewCmd.Flags().IntVar(&viewMaxLines, "max-lines", 0, "Maximum number of lines for a wrapped row")
+	viewCmd.Flags().StringVar(&viewFit, "fit", "none", "Fit the table to the terminal width (none, shrink, fold, both)")
+	viewCmd.Flags().IntVar(&viewWidth, "width", 0, "Width to fit the table to (default: the terminal width)")
+	viewCmd.Flags().BoolVarP(&viewVertical, "vertical", "x", false, "Show each record as a block of \"name | value\" lines")
+	viewCmd.Flags().StringVar(&viewColor, "color", "auto", "Use colors (auto, always, never)")
+	viewCmd.Flags().BoolVar(&viewZebra, "zebra", false, "Color every other row (instead of every other column)")
+	viewCmd.Flags().StringVar(&viewHighlight, "highlight", "", "Highlight values matching this regular expression")
+	viewCmd.Flags().BoolVar(&viewExactWidths, "exact-widths", false, "Read the entire file first to find the column widths (not for stdin)")
+	viewCmd.Flags().IntVar(&viewRepeatHeader, "repeat-header", 0, "Repeat the header every N rows")
+	viewCmd.Flags().BoolVar(&viewSummary, "summary", false, "Show the number of rows, comments and columns after the table")
 	rootCmd.AddCommand(viewCmd)
 }
 
 var viewCmd = &cobra.Command{
-	Use:   "view",
+	Use:   "view [file...]",
 	Short: "Pretty-print of a tabular file",
+	Long: `Pretty-print of a tabular file.
+
+Columns where all of the values are numbers (or missing: NA, ., etc) are
+right-aligned. The number formatting options only change how the values are
+shown, not the values themselves.
+
+The --style option can be used to write the table for pasting into other
+documents: box (unicode box drawing), grid (ASCII), markdown (GitHub), rst
+(reStructuredText grid table), or org (org-mode).
+
+When writing to a terminal, --fit can be used to make wide tables fit the
+terminal width: shrink (shrink the columns), fold (write the columns that
+don't fit as another table), or both (fold, then shrink any column that is
+too wide on its own).
+
+Column widths are estimated from the first 10,000 lines, which are kept in
+memory. Longer values after that are truncated. With --exact-widths, the
+entire file is read once to find the widths and then read again to show it.
+This takes about twice as long, but doesn't use any more memory (and also
+works for gzip files). It can't be used with stdin.
+`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) > 0 && args[0] != "-" {
-			_, err := os.Stat(args[0])
-			if os.IsNotExist(err) {
-				return fmt.Errorf("Missing file: %s", args[0])
+		if viewExactWidths {
+			for _, arg := range args {
+				if arg == "-" {
+					return errors.New("--exact-widths can't be used with stdin")
+				}
+			}
+			if len(args) == 0 {
+				return errors.New("--exact-widths can't be used with stdin")
 			}
 		}
-		return nil
+		return checkFiles(args)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
-			args = []string{"-"}
+		style, err := textfile.NewTableStyle(viewStyle)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
-		var txt *textfile.DelimitedTextFile
-		if !IsCSV {
-			txt = textfile.NewTabFile(args[0])
-		} else {
-			txt = textfile.NewCSVFile(args[0])
+
+		if viewFit != "none" && viewFit != "shrink" && viewFit != "fold" && viewFit != "both" {
+			fmt.Fprintf(os.Stderr, "Unknown value for --fit: %s (valid: none, shrink, fold, both)\n", viewFit)
+			os.Exit(1)
 		}
 
-		txt = txt.WithNoHeader(NoHeader).
-			WithHeaderComment(HeaderComment)
+		var color bool
+		switch viewColor {
+		case "always":
+			color = true
+		case "never":
+			color = false
+		case "auto":
+			color = support.IsTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown value for --color: %s (valid: auto, always, never)\n", viewColor)
+			os.Exit(1)
+		}
+
+		var highlight *regexp.Regexp
+		if viewHighlight != "" {
+			highlight, err = regexp.Compile(viewHighlight)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid --highlight regular expression: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		// wrapping needs a column width, so without --max or --fit, the columns are shrunk to fit
+		fit := viewFit
+		wrap := viewWrap || viewWrapWords
+		if wrap && MaxWidth == 0 && fit == "none" {
+			fit = "shrink"
+		}
+
+		fitWidth := 0
+		if fit != "none" {
+			fitWidth = viewWidth
+			if fitWidth == 0 && support.IsTerminal(os.Stdout) {
+				fitWidth = support.TerminalWidth(os.Stdout)
+			}
+		}
+		if wrap && MaxWidth == 0 && fitWidth == 0 {
+			fmt.Fprintln(os.Stderr, "--wrap needs a column width: use --max, or --width when not writing to a terminal")
+			os.Exit(1)
+		}
+
+		txt, err := openFiles(args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 
 		textfile.NewTextViewer(txt).
 			WithShowComments(ShowComments).
 			WithShowLineNum(ShowLineNum).
 			WithMaxWidth(MaxWidth).
 			WithMinWidth(MinWidth).
+			WithStyle(style).
+			WithWrap(wrap).
+			WithWrapWords(viewWrapWords).
+			WithMaxRowHeight(viewMaxLines).
+			WithFitWidth(fitWidth).
+			WithShrink(fit == "shrink" || fit == "both").
+			WithFold(fit == "fold" || fit == "both").
+			WithVertical(viewVertical).
+			WithColor(color).
+			WithZebra(viewZebra).
+			WithHighlight(highlight).
+			WithExactWidths(viewExactWidths).
+			WithRepeatHeader(viewRepeatHeader).
+			WithSummary(viewSummary).
+			WithNumberFormat(textfile.NewNumberFormat().
+				WithDecimals(viewDecimals).
+				WithSigDigits(viewSigDigits).
+				WithThousands(viewThousands).
+				WithSciAbove(viewSciAbove).
+				WithSciBelow(viewSciBelow)).
 			WriteFile(os.Stdout)
 	},
 }
@@ -4,6 +4,8 @@ go 1.14
 
 require (
 	github.com/gizak/termui/v3 v3.1.0
+	github.com/mattn/go-runewidth v0.0.9
 	github.com/nsf/termbox-go v0.0.0-20190121233118-02980233997d
 	github.com/spf13/cobra v1.0.0
+	golang.org/x/term v0.0.0-20201210144234-2321bbc49cbf
 )
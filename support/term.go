@@ -0,0 +1,27 @@
+package support
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+)
+
+// IsTerminal - is this file a terminal (tty)?
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// TerminalWidth - return the width (columns) of the terminal for a file. If the width can't be
+// found from the terminal, $COLUMNS is used. Returns 0 if the width isn't known.
+func TerminalWidth(f *os.File) int {
+	if IsTerminal(f) {
+		if w, _, err := term.GetSize(int(f.Fd())); err == nil && w > 0 {
+			return w
+		}
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return 0
+}
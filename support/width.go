@@ -0,0 +1,76 @@
+package support
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// StringWidth - return the number of terminal cells needed to show a string
+// (wide east-asian characters take two cells, combining and zero-width characters take none)
+func StringWidth(s string) int {
+	return runewidth.StringWidth(s)
+}
+
+// TruncateWidth - return the longest prefix of a string that fits in w terminal cells
+func TruncateWidth(s string, w int) string {
+	width := 0
+	for i, r := range s {
+		cw := runewidth.RuneWidth(r)
+		if width+cw > w {
+			return s[:i]
+		}
+		width += cw
+	}
+	return s
+}
+
+// PadRight - pad a string with spaces so that it fills w terminal cells
+func PadRight(s string, w int) string {
+	width := StringWidth(s)
+	if width >= w {
+		return s
+	}
+	return s + strings.Repeat(" ", w-width)
+}
+
+// PadLeft - pad a string with spaces on the left so that it fills w terminal cells
+func PadLeft(s string, w int) string {
+	width := StringWidth(s)
+	if width >= w {
+		return s
+	}
+	return strings.Repeat(" ", w-width) + s
+}
+
+// WrapWidth - split a string into lines that each fit in w terminal cells. If words is true,
+// lines are broken at spaces where possible (long words are still split).
+func WrapWidth(s string, w int, words bool) []string {
+	if w < 1 {
+		return []string{s}
+	}
+
+	var lines []string
+	for StringWidth(s) > w {
+		line := TruncateWidth(s, w)
+		if line == "" {
+			// a single character that is wider than the column
+			_, size := utf8.DecodeRuneInString(s)
+			line = s[:size]
+		}
+
+		if !words {
+			lines = append(lines, line)
+			s = s[len(line):]
+			continue
+		}
+
+		if idx := strings.LastIndex(line, " "); idx > 0 && s[len(line)] != ' ' {
+			line = line[:idx]
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+		s = strings.TrimLeft(s[len(line):], " ")
+	}
+	return append(lines, s)
+}
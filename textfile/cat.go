@@ -0,0 +1,78 @@
+package textfile
+
+import (
+	"io"
+)
+
+// TextCat is used to write a (combined) delimited text file with a single header
+type TextCat struct {
+	txt          *DelimitedTextFile
+	showComments bool
+}
+
+// NewTextCat - create a new text concatenator. This is usually used with the combined file from a MultiTextFile.
+func NewTextCat(f *DelimitedTextFile) *TextCat {
+	return &TextCat{
+		txt:          f,
+		showComments: false,
+	}
+}
+
+// WithShowComments - set showing comments
+func (tc *TextCat) WithShowComments(b bool) *TextCat {
+	tc.showComments = b
+	return tc
+}
+
+// WriteFile - write the rows to the given stream
+func (tc *TextCat) WriteFile(out io.Writer) error {
+	var line *TextRecord
+	var err error = nil
+	wroteHeader := false
+
+	// the header and comments are written the same way as for sorting
+	writer := NewTextSorter(tc.txt, nil).WithShowComments(tc.showComments)
+
+	var commentsBefore []*TextRecord
+	var commentsAfter []*TextRecord
+
+	defer tc.txt.Close()
+
+	for err == nil {
+		line, err = tc.txt.ReadLine()
+		if line == nil {
+			break
+		}
+
+		if line.Values == nil {
+			// comment
+			if !wroteHeader {
+				if tc.txt.Header != nil && !tc.txt.headerComment {
+					commentsAfter = append(commentsAfter, line)
+				} else {
+					commentsBefore = append(commentsBefore, line)
+				}
+			} else if tc.showComments {
+				writer.writeLine(out, line)
+			}
+			continue
+		}
+
+		if !wroteHeader {
+			writer.writeHeaderBlock(out, commentsBefore, commentsAfter, line.RawString)
+			wroteHeader = true
+		}
+
+		writer.writeLine(out, line)
+	}
+
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	// without any rows, the header is still written
+	if !wroteHeader && tc.txt.Header != nil {
+		writer.writeHeaderBlock(out, commentsBefore, commentsAfter, "")
+	}
+	return nil
+}
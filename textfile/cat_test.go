@@ -0,0 +1,103 @@
+package textfile_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mbreese/tabl/textfile"
+)
+
+func TestCatReorder(t *testing.T) {
+	txt, err := textfile.NewMultiFile([]*textfile.DelimitedTextFile{
+		textfile.NewTabFile("testdata/merge1.txt"),
+		textfile.NewTabFile("testdata/merge_reorder.txt"),
+	}).WithReorderColumns(true).Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := textfile.NewTextCat(txt).WriteFile(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "chrom\tpos\tname\n" +
+		"A\t1\tone\n" +
+		"A\t5\tfive\n" +
+		"B\t2\ttwo\n" +
+		"B\t3\tthree\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected cat output:\n%s", buf.String())
+	}
+}
+
+func TestCatIntersect(t *testing.T) {
+	mf := textfile.NewMultiFile([]*textfile.DelimitedTextFile{
+		textfile.NewTabFile("testdata/merge1.txt"),
+		textfile.NewTabFile("testdata/merge_bad.txt"),
+	}).WithIntersectHeader(true)
+
+	txt, err := mf.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := textfile.NewTextCat(txt).WriteFile(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(txt.Header) != 2 || txt.Header[0] != "chrom" || txt.Header[1] != "name" {
+		t.Errorf("Unexpected header: %v", txt.Header)
+	}
+
+	var report bytes.Buffer
+	mf.WriteHeaderReport(&report)
+	if report.String() != "testdata/merge1.txt: added chrom, name\ntestdata/merge1.txt: dropped pos\ntestdata/merge_bad.txt: dropped start\n" {
+		t.Errorf("Unexpected header report:\n%s", report.String())
+	}
+}
+
+func TestCatHeaderOnly(t *testing.T) {
+	txt, err := textfile.NewMultiFile([]*textfile.DelimitedTextFile{
+		textfile.NewTabFile("testdata/merge_empty.txt"),
+		textfile.NewTabFile("testdata/merge_empty.txt"),
+	}).Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := textfile.NewTextCat(txt).WriteFile(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "chrom\tpos\tname\n" {
+		t.Errorf("Unexpected cat output:\n%s", buf.String())
+	}
+}
+
+func TestCatEmptyFirst(t *testing.T) {
+	txt, err := textfile.NewMultiFile([]*textfile.DelimitedTextFile{
+		textfile.NewTabFile("testdata/merge_empty.txt"),
+		textfile.NewTabFile("testdata/merge1.txt"),
+	}).Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := textfile.NewTextCat(txt).WriteFile(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "chrom\tpos\tname\n" +
+		"A\t1\tone\n" +
+		"A\t5\tfive\n" +
+		"B\t2\ttwo\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected cat output:\n%s", buf.String())
+	}
+}
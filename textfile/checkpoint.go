@@ -0,0 +1,335 @@
+package textfile
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+// checkpointInterval is the (minimum) number of lines between checkpoints
+const checkpointInterval = 1000
+
+// checkpoint is the start of a line in a file, where reading can start again
+type checkpoint struct {
+	offset      int64 // uncompressed byte offset
+	lineNum     int   // number of lines before this one
+	dataLineNum int   // number of data lines before this one
+}
+
+// fileLine - the line number of a record in the file that it was read from. This is the same as
+// LineNum, except for a file with the original line numbers (like a sorted file in the pager).
+func (t *TextRecord) fileLine() int {
+	return t.start.lineNum + 1
+}
+
+// fileDataLine - the data line number of a record in the file that it was read from
+func (t *TextRecord) fileDataLine() int {
+	return t.start.dataLineNum + 1
+}
+
+// fileIndex is a list of checkpoints for a file, which are added as the file is read. For gzip
+// files, it also has a cache (see gzipCache), because we can't start reading a gzip file from the
+// middle.
+type fileIndex struct {
+	checkpoints []checkpoint
+	gzip        *gzipCache
+}
+
+// add - add a checkpoint (if it is far enough past the last one)
+func (idx *fileIndex) add(cp checkpoint) {
+	n := len(idx.checkpoints)
+	if n == 0 || cp.lineNum >= idx.checkpoints[n-1].lineNum+checkpointInterval {
+		idx.checkpoints = append(idx.checkpoints, cp)
+	}
+}
+
+// before - the last checkpoint that is before a line (or the start of the file)
+func (idx *fileIndex) before(lineNum int) checkpoint {
+	i := idx.countBefore(lineNum)
+	if i == 0 {
+		return checkpoint{}
+	}
+	return idx.checkpoints[i-1]
+}
+
+// countBefore - the number of checkpoints before a line
+func (idx *fileIndex) countBefore(lineNum int) int {
+	return sort.Search(len(idx.checkpoints), func(i int) bool {
+		return idx.checkpoints[i].lineNum+1 >= lineNum
+	})
+}
+
+// beforeData - the last checkpoint that is at or before a data line (or the start of the file)
+func (idx *fileIndex) beforeData(dataLineNum int) checkpoint {
+	i := sort.Search(len(idx.checkpoints), func(i int) bool {
+		return idx.checkpoints[i].dataLineNum+1 > dataLineNum
+	})
+	if i == 0 {
+		return checkpoint{}
+	}
+	return idx.checkpoints[i-1]
+}
+
+// close - remove the gzip cache (if there is one)
+func (idx *fileIndex) close() {
+	if idx != nil && idx.gzip != nil {
+		idx.gzip.close()
+		idx.gzip = nil
+	}
+}
+
+// gzipBlockSize is the (uncompressed) size of the blocks in a gzip cache
+var gzipBlockSize = 1 << 20
+
+// gzipCache keeps restart points for a gzip file. A gzip file can only be read from the start (or
+// the start of a gzip member), so as the file is read, the uncompressed data is also written to a
+// temp file in blocks that are compressed separately. Reading from the middle of the file again
+// only needs to uncompress one block. Each block is written once, the first time the data is read,
+// and the temp file is about the size of the gzip file. The data after the last block is kept in
+// memory until the block is full.
+type gzipCache struct {
+	mu      sync.Mutex
+	src     io.ReadCloser
+	err     error
+	tmp     *os.File
+	fw      *flate.Writer
+	offsets []int64
+	pending []byte
+}
+
+// newGzipCache - start a cache for a gzip stream (which is read from the start)
+func newGzipCache(src io.ReadCloser) *gzipCache {
+	return &gzipCache{
+		src:     src,
+		offsets: []int64{0},
+		pending: make([]byte, 0, gzipBlockSize),
+	}
+}
+
+// reader - a new reader for the uncompressed data, starting at an offset
+func (c *gzipCache) reader(offset int64) io.ReadCloser {
+	return &gzipCacheReader{c: c, pos: offset}
+}
+
+// readAt - read the data at an offset. For a full block, this is the entire block (uncompressed).
+// Otherwise, it is the data in memory after the last block, which is read from the gzip file if
+// needed. Returns the data and the offset of the start of the data.
+func (c *gzipCache) readAt(pos int64) ([]byte, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		blocks := len(c.offsets) - 1
+		if k := pos / int64(gzipBlockSize); k < int64(blocks) {
+			buf, err := c.readBlock(k)
+			return buf, k * int64(gzipBlockSize), err
+		}
+
+		start := int64(blocks) * int64(gzipBlockSize)
+		if pos < start+int64(len(c.pending)) {
+			return c.pending, start, nil
+		}
+		if c.err != nil {
+			return nil, 0, c.err
+		}
+		c.fill()
+	}
+}
+
+// readBlock - uncompress a block from the temp file
+func (c *gzipCache) readBlock(k int64) ([]byte, error) {
+	section := io.NewSectionReader(c.tmp, c.offsets[k], c.offsets[k+1]-c.offsets[k])
+	rd := flate.NewReader(section)
+	defer rd.Close()
+
+	buf := make([]byte, gzipBlockSize)
+	n, err := io.ReadFull(rd, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// fill - read more of the gzip file, writing a block to the temp file when it is full
+func (c *gzipCache) fill() {
+	n, err := c.src.Read(c.pending[len(c.pending):cap(c.pending)])
+	c.pending = c.pending[:len(c.pending)+n]
+	if err != nil {
+		c.err = err
+	}
+	if len(c.pending) == cap(c.pending) {
+		if err := c.writeBlock(); err != nil {
+			c.err = err
+		}
+	}
+}
+
+// writeBlock - compress the data in memory into a new block
+func (c *gzipCache) writeBlock() error {
+	if c.tmp == nil {
+		tmp, err := ioutil.TempFile("", "tabl_gzip")
+		if err != nil {
+			return err
+		}
+		fw, err := flate.NewWriter(tmp, flate.BestSpeed)
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+		c.tmp = tmp
+		c.fw = fw
+	} else {
+		c.fw.Reset(c.tmp)
+	}
+
+	if _, err := c.fw.Write(c.pending); err != nil {
+		return err
+	}
+	if err := c.fw.Close(); err != nil {
+		return err
+	}
+	end, err := c.tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	c.offsets = append(c.offsets, end)
+
+	// (readers may still have the old data, so this isn't re-used)
+	c.pending = make([]byte, 0, gzipBlockSize)
+	return nil
+}
+
+// close - close the gzip file and remove the temp file
+func (c *gzipCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.src.Close()
+	if c.tmp != nil {
+		c.tmp.Close()
+		os.Remove(c.tmp.Name())
+		c.tmp = nil
+	}
+	if c.err == nil {
+		c.err = errors.New("File is closed")
+	}
+}
+
+// gzipFileReader is a gzip reader that also closes the file
+type gzipFileReader struct {
+	*gzip.Reader
+	f io.Closer
+}
+
+// Close the gzip reader and the file
+func (g *gzipFileReader) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
+}
+
+// gzipCacheReader reads the uncompressed data from a gzip cache (keeping the last block it read)
+type gzipCacheReader struct {
+	c     *gzipCache
+	pos   int64
+	buf   []byte
+	start int64
+}
+
+func (r *gzipCacheReader) Read(p []byte) (int, error) {
+	if r.pos < r.start || r.pos >= r.start+int64(len(r.buf)) {
+		buf, start, err := r.c.readAt(r.pos)
+		if err != nil {
+			return 0, err
+		}
+		r.buf = buf
+		r.start = start
+	}
+	n := copy(p, r.buf[r.pos-r.start:])
+	r.pos += int64(n)
+	return n, nil
+}
+
+// Close the reader (the cache is closed with the index)
+func (r *gzipCacheReader) Close() error {
+	r.buf = nil
+	return nil
+}
+
+// withIndex - keep checkpoints while reading, so that the file can be re-read from the
+// middle (see seek). This only works for a single file (not stdin), and has to be set
+// before the file is read.
+func (txt *DelimitedTextFile) withIndex() bool {
+	if txt.multi != nil || txt.Filename == "-" || txt.Filename == "" || txt.rd != nil {
+		return false
+	}
+	txt.index = &fileIndex{}
+	return true
+}
+
+// seek - move to a checkpoint, so that the next line read is the one at the checkpoint
+func (txt *DelimitedTextFile) seek(cp checkpoint) error {
+	var rd io.ReadCloser
+	if cp.offset > 0 && txt.index.gzip != nil {
+		rd = txt.index.gzip.reader(cp.offset)
+	} else if cp.offset > 0 {
+		f, err := os.Open(txt.Filename)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Seek(cp.offset, io.SeekStart); err != nil {
+			f.Close()
+			return err
+		}
+		rd = f
+	}
+
+	if txt.rd != nil {
+		txt.rd.Close()
+	}
+	// (if rd is nil, the file will be opened again from the start)
+	txt.rd = rd
+	txt.pos = 0
+	txt.bufLen = 0
+	txt.hasNext = false
+	txt.nextWidth = 0
+	txt.isEOF = false
+	txt.offset = cp.offset
+	txt.curLineNum = cp.lineNum
+	txt.curDataLineNum = cp.dataLineNum
+
+	if cp.offset == 0 {
+		// starting over, so read the header again
+		txt.Header = nil
+		txt.lastComment = ""
+		txt.rawHeaderLine = ""
+	}
+	return nil
+}
+
+// cloneAt - a new reader for the same file, starting at a checkpoint
+func (txt *DelimitedTextFile) cloneAt(cp checkpoint) (*DelimitedTextFile, error) {
+	c := txt.Clone(txt.Filename).
+		WithNoHeader(txt.noHeader).
+		WithHeaderComment(txt.headerComment)
+	c.index = txt.index
+	c.lineNums = txt.lineNums
+	c.Header = make([]string, len(txt.Header))
+	copy(c.Header, txt.Header)
+	c.rawHeaderLine = txt.rawHeaderLine
+
+	if err := c.seek(cp); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// position - the checkpoint for the next line to be read
+func (txt *DelimitedTextFile) position() checkpoint {
+	return checkpoint{txt.offset, txt.curLineNum, txt.curDataLineNum}
+}
@@ -0,0 +1,185 @@
+package textfile
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileIndexBefore(t *testing.T) {
+	idx := &fileIndex{}
+	idx.add(checkpoint{offset: 10, lineNum: 1, dataLineNum: 0})
+	idx.add(checkpoint{offset: 500, lineNum: 50, dataLineNum: 49}) // too close to the last one
+	idx.add(checkpoint{offset: 9000, lineNum: 1001, dataLineNum: 1000})
+	idx.add(checkpoint{offset: 18000, lineNum: 2001, dataLineNum: 2000})
+
+	if len(idx.checkpoints) != 3 {
+		t.Fatalf("Expected 3 checkpoints, got %d", len(idx.checkpoints))
+	}
+
+	tests := []struct {
+		lineNum int
+		offset  int64
+	}{
+		{1, 0},
+		{2, 0},
+		{3, 10},
+		{1002, 10},
+		{1003, 9000},
+		{5000, 18000},
+	}
+	for _, test := range tests {
+		if cp := idx.before(test.lineNum); cp.offset != test.offset {
+			t.Errorf("before(%d): expected offset %d, got %d", test.lineNum, test.offset, cp.offset)
+		}
+	}
+
+	dataTests := []struct {
+		dataLineNum int
+		offset      int64
+	}{
+		{0, 0},
+		{1, 10},
+		{1000, 10},
+		{1001, 9000},
+		{2001, 18000},
+	}
+	for _, test := range dataTests {
+		if cp := idx.beforeData(test.dataLineNum); cp.offset != test.offset {
+			t.Errorf("beforeData(%d): expected offset %d, got %d", test.dataLineNum, test.offset, cp.offset)
+		}
+	}
+}
+
+// writeSeekFile - write a test file with a header and rows (as plain text, gzip, or gzip with a
+// member for every 100 rows)
+func writeSeekFile(t *testing.T, dir string, name string) string {
+	fname := filepath.Join(dir, name)
+	f, err := os.Create(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var gz *gzip.Writer
+	write := func(s string) {
+		if gz != nil {
+			gz.Write([]byte(s))
+		} else {
+			f.Write([]byte(s))
+		}
+	}
+	if filepath.Ext(name) == ".gz" {
+		gz = gzip.NewWriter(f)
+	}
+
+	write("# comment\nid\tname\n")
+	for i := 1; i <= 5000; i++ {
+		write(fmt.Sprintf("%d\tname-%d\n", i, i))
+		if name == "members.gz" && i%100 == 0 {
+			gz.Close()
+			gz = gzip.NewWriter(f)
+		}
+	}
+	if gz != nil {
+		gz.Close()
+	}
+	return fname
+}
+
+func TestSeek(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tabl_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// (small blocks, so that the gzip cache has more than one)
+	defer func(size int) { gzipBlockSize = size }(gzipBlockSize)
+	gzipBlockSize = 4096
+
+	for _, name := range []string{"plain.txt", "single.gz", "members.gz"} {
+		txt := NewTabFile(writeSeekFile(t, dir, name))
+		if !txt.withIndex() {
+			t.Fatal("Expected an index")
+		}
+
+		// read half of the file, then check a clone that reads past it (into the cache)
+		lines := make(map[int]*TextRecord)
+		for i := 0; i < 2500; i++ {
+			l, err := txt.ReadLine()
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines[l.LineNum] = l
+		}
+		rd, err := txt.cloneAt(txt.position())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for l, err := rd.ReadLine(); err == nil; l, err = rd.ReadLine() {
+			lines[l.LineNum] = l
+		}
+		rd.Close()
+		if l := lines[5002]; l == nil || l.RawString != "5000\tname-5000\n" {
+			t.Errorf("%s: unexpected last line: %v", name, l)
+		}
+
+		for _, cp := range txt.index.checkpoints {
+			rd, err := txt.cloneAt(cp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l, err := rd.ReadLine()
+			rd.Close()
+			if err != nil {
+				t.Fatalf("%s: unable to read at %v: %v", name, cp, err)
+			}
+			expected := lines[cp.lineNum+1]
+			if l.RawString != expected.RawString || l.DataLineNum != expected.DataLineNum {
+				t.Errorf("%s: checkpoint %v, expected %q (%d), got %q (%d)", name, cp, expected.RawString, expected.DataLineNum, l.RawString, l.DataLineNum)
+			}
+		}
+
+		// back to a checkpoint, and then back to the start (which reads the header again)
+		cp := txt.index.before(3000)
+		if err := txt.seek(cp); err != nil {
+			t.Fatal(err)
+		}
+		if l, err := txt.ReadLine(); err != nil || l.RawString != lines[cp.lineNum+1].RawString {
+			t.Errorf("%s: unexpected line after seek: %v %v", name, l, err)
+		}
+		if err := txt.seek(checkpoint{}); err != nil {
+			t.Fatal(err)
+		}
+		for {
+			l, err := txt.ReadLine()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l.Values != nil {
+				if l.RawString != "1\tname-1\n" || len(txt.Header) != 2 || txt.Header[0] != "id" {
+					t.Errorf("%s: unexpected first line: %q (header: %v)", name, l.RawString, txt.Header)
+				}
+				break
+			}
+		}
+
+		var tmp string
+		if txt.index.gzip != nil && txt.index.gzip.tmp != nil {
+			tmp = txt.index.gzip.tmp.Name()
+		}
+		txt.Close()
+		txt.index.close()
+		if tmp != "" {
+			if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+				t.Errorf("%s: the gzip cache wasn't removed: %s", name, tmp)
+			}
+		} else if name != "plain.txt" {
+			t.Errorf("%s: expected a gzip cache", name)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package textfile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// copyText - copy text to the clipboard. This uses an OSC 52 escape sequence, so the terminal
+// (not the host we are running on) sets the clipboard, which also works over ssh. If there
+// isn't a terminal to write to, and there is a copy command (like "xclip -selection clipboard"),
+// the text is piped to the command instead.
+func (tv *TextPager) copyText(s string) error {
+	err := writeOSC52(tv.tty, s)
+	if err != nil && tv.copyCmd != "" {
+		return runCopyCmd(tv.copyCmd, s)
+	}
+	return err
+}
+
+// writeOSC52 - write the OSC 52 sequence for the text to the terminal. This is written to the
+// terminal directly, because stdout may not be the terminal.
+func writeOSC52(ttyName string, s string) error {
+	tty, err := os.OpenFile(ttyName, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+
+	tmux := os.Getenv("TMUX") != ""
+	screen := !tmux && strings.HasPrefix(os.Getenv("TERM"), "screen")
+	_, err = tty.WriteString(osc52(s, tmux, screen))
+	return err
+}
+
+// copyCell - copy the value of the active column in the active row
+func (tv *TextPager) copyCell() string {
+	t := tv.activeRecord()
+	col := tv.activeColumn()
+	if t == nil || t.Values == nil {
+		return " Nothing to copy"
+	}
+
+	v := ""
+	if col < len(t.Values) {
+		v = t.Values[col]
+	}
+	if err := tv.copyText(v); err != nil {
+		return " Unable to copy: " + err.Error()
+	}
+	return " Copied " + tv.colNames[col]
+}
+
+// copyRow - copy the active row (as a TSV or CSV line)
+func (tv *TextPager) copyRow(format int) string {
+	t := tv.activeRecord()
+	if t == nil || t.Values == nil {
+		return " Nothing to copy"
+	}
+
+	if err := tv.copyText(joinFunc(format)(t.Values) + "\n"); err != nil {
+		return " Unable to copy: " + err.Error()
+	}
+	return fmt.Sprintf(" Copied line %d (%s)", t.DataLineNum, saveFormatNames[format])
+}
+
+// copyMarked - copy the marked rows, with the header (as TSV or CSV)
+func (tv *TextPager) copyMarked(format int) string {
+	if tv.markCount() == 0 {
+		return " No rows marked"
+	}
+
+	join := joinFunc(format)
+	var sb strings.Builder
+	if !tv.txt.noHeader {
+		sb.WriteString(join(tv.colNames) + "\n")
+	}
+	count := 0
+	err := tv.eachSavedRow(true, func(t *TextRecord) error {
+		sb.WriteString(join(t.Values) + "\n")
+		count++
+		return nil
+	})
+	if err == nil {
+		err = tv.copyText(sb.String())
+	}
+	if err != nil {
+		return " Unable to copy: " + err.Error()
+	}
+	return fmt.Sprintf(" Copied %d marked rows (%s)", count, saveFormatNames[format])
+}
+
+// runCopyCmd - pipe text to a command (with sh, so the command can have arguments or pipes)
+func runCopyCmd(command string, s string) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = strings.NewReader(s)
+	return cmd.Run()
+}
+
+// osc52 - the OSC 52 escape sequence to set the clipboard. tmux and screen only pass escape
+// sequences on to the terminal if they are wrapped in a DCS sequence (and screen has a limit on
+// the length of a DCS sequence, so it is split into chunks).
+func osc52(s string, tmux bool, screen bool) string {
+	data := base64.StdEncoding.EncodeToString([]byte(s))
+
+	switch {
+	case tmux:
+		// (escape characters in the sequence are doubled)
+		return "\x1bPtmux;\x1b\x1b]52;c;" + data + "\x07\x1b\\"
+	case screen:
+		var buf bytes.Buffer
+		buf.WriteString("\x1bP\x1b]52;c;")
+		for len(data) > 76 {
+			buf.WriteString(data[:76] + "\x1b\\\x1bP")
+			data = data[76:]
+		}
+		buf.WriteString(data + "\x07\x1b\\")
+		return buf.String()
+	}
+	return "\x1b]52;c;" + data + "\x07"
+}
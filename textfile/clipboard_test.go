@@ -0,0 +1,124 @@
+package textfile
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOSC52(t *testing.T) {
+	tests := []struct {
+		s        string
+		tmux     bool
+		screen   bool
+		expected string
+	}{
+		{"hello", false, false, "\x1b]52;c;aGVsbG8=\x07"},
+		{"", false, false, "\x1b]52;c;\x07"},
+		{"hello", true, false, "\x1bPtmux;\x1b\x1b]52;c;aGVsbG8=\x07\x1b\\"},
+		{"hello", false, true, "\x1bP\x1b]52;c;aGVsbG8=\x07\x1b\\"},
+		// tmux wins (TERM is usually screen in tmux)
+		{"hello", true, true, "\x1bPtmux;\x1b\x1b]52;c;aGVsbG8=\x07\x1b\\"},
+	}
+
+	for _, test := range tests {
+		if got := osc52(test.s, test.tmux, test.screen); got != test.expected {
+			t.Errorf("osc52(%q, %v, %v): got %q, expected %q", test.s, test.tmux, test.screen, got, test.expected)
+		}
+	}
+}
+
+func TestOSC52ScreenChunks(t *testing.T) {
+	tests := []struct {
+		size   int
+		chunks int
+	}{
+		{57, 1}, // 76 base64 characters
+		{58, 2},
+		{114, 2}, // 152
+		{115, 3},
+		{1000, 18},
+	}
+
+	for _, test := range tests {
+		s := strings.Repeat("x", test.size)
+		got := osc52(s, false, true)
+
+		if !strings.HasPrefix(got, "\x1bP\x1b]52;c;") || !strings.HasSuffix(got, "\x07\x1b\\") {
+			t.Errorf("%d bytes: unexpected start or end: %q", test.size, got)
+			continue
+		}
+		body := strings.TrimSuffix(strings.TrimPrefix(got, "\x1bP\x1b]52;c;"), "\x07\x1b\\")
+		chunks := strings.Split(body, "\x1b\\\x1bP")
+		if len(chunks) != test.chunks {
+			t.Errorf("%d bytes: expected %d chunks, got %d", test.size, test.chunks, len(chunks))
+		}
+		for i, c := range chunks {
+			if len(c) > 76 || (i < len(chunks)-1 && len(c) != 76) {
+				t.Errorf("%d bytes: chunk %d has %d characters", test.size, i, len(c))
+			}
+		}
+		if data, err := base64.StdEncoding.DecodeString(strings.Join(chunks, "")); err != nil || string(data) != s {
+			t.Errorf("%d bytes: the chunks don't decode to the text (%v)", test.size, err)
+		}
+	}
+}
+
+func TestCopyText(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tabl-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// (without tmux or screen)
+	for _, name := range []string{"TMUX", "TERM"} {
+		if v, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, v)
+		} else {
+			defer os.Unsetenv(name)
+		}
+	}
+	os.Unsetenv("TMUX")
+	os.Setenv("TERM", "xterm")
+
+	tty := filepath.Join(dir, "tty")
+	copied := filepath.Join(dir, "copied")
+	noTTY := filepath.Join(dir, "missing", "tty")
+
+	tests := []struct {
+		tty     string
+		copyCmd string
+		written string
+		copied  string
+		err     bool
+	}{
+		// OSC 52 is used first (even with a copy command)
+		{tty, "", "\x1b]52;c;aGVsbG8=\x07", "", false},
+		{tty, "cat > " + copied, "\x1b]52;c;aGVsbG8=\x07", "", false},
+		// the command is used if there isn't a terminal
+		{noTTY, "cat > " + copied, "", "hello", false},
+		{noTTY, "", "", "", true},
+		{noTTY, "exit 1", "", "", true},
+	}
+
+	for _, test := range tests {
+		ioutil.WriteFile(tty, nil, 0644)
+		os.Remove(copied)
+
+		tv := NewTextPager(nil).WithCopyCommand(test.copyCmd)
+		tv.tty = test.tty
+		if err := tv.copyText("hello"); (err != nil) != test.err {
+			t.Errorf("%s (%q): unexpected error: %v", test.tty, test.copyCmd, err)
+		}
+		if b, _ := ioutil.ReadFile(tty); string(b) != test.written {
+			t.Errorf("%s (%q): expected %q to be written to the terminal, got %q", test.tty, test.copyCmd, test.written, b)
+		}
+		if b, _ := ioutil.ReadFile(copied); string(b) != test.copied {
+			t.Errorf("%s (%q): expected %q to be copied by the command, got %q", test.tty, test.copyCmd, test.copied, b)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package textfile
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ANSI color codes used by the viewer
+const (
+	colorReset   = "\x1b[0m"
+	colorHeader  = "\x1b[1;4m"
+	colorComment = "\x1b[2m"
+	colorLineNum = "\x1b[32m"
+	colorAltCol  = "\x1b[36m"
+	colorZebra   = "\x1b[48;5;236m"
+	colorMatch   = "\x1b[1;33m"
+)
+
+// colorize - add a color to a string. Any spaces at the start and end of the string aren't colored
+// (so that padding isn't underlined, etc). The base color is restored after the reset.
+func colorize(s string, color string, base string) string {
+	trimmed := strings.TrimLeft(s, " ")
+	left := s[:len(s)-len(trimmed)]
+	inner := strings.TrimRight(trimmed, " ")
+	right := trimmed[len(inner):]
+
+	if inner == "" {
+		return s
+	}
+	return left + color + inner + colorReset + base + right
+}
+
+// highlightMatches - color all of the matches for a regular expression. The base color is restored
+// after each match.
+func highlightMatches(s string, re *regexp.Regexp, base string) string {
+	if re == nil {
+		return s
+	}
+	return re.ReplaceAllStringFunc(s, func(m string) string {
+		if m == "" {
+			return m
+		}
+		return colorMatch + m + colorReset + base
+	})
+}
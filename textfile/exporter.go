@@ -3,7 +3,6 @@ package textfile
 import (
 	"fmt"
 	"io"
-	"strings"
 )
 
 // TextExporter is used to export specific columns from a tab delimited file
@@ -149,23 +148,5 @@ func (tex *TextExporter) writeLine(out io.Writer, line *TextRecord) error {
 }
 
 func (tex *TextExporter) csvQuoteString(inp string) string {
-	quote := false
-	if strings.Index(inp, "\r") != -1 {
-		quote = true
-	}
-	if strings.Index(inp, "\n") != -1 {
-		quote = true
-	}
-	if tex.txt.Quote != 0 && strings.Index(inp, string(tex.txt.Quote)) != -1 {
-		quote = true
-	}
-	if strings.Index(inp, string(tex.txt.Delim)) != -1 {
-		quote = true
-	}
-
-	if quote {
-		dblq := []rune{tex.txt.Quote, tex.txt.Quote}
-		return string(tex.txt.Quote) + strings.ReplaceAll(inp, string(tex.txt.Quote), string(dblq)) + string(tex.txt.Quote)
-	}
-	return inp
+	return tex.txt.quoteValue(inp)
 }
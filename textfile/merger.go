@@ -0,0 +1,148 @@
+package textfile
+
+import (
+	"fmt"
+	"io"
+)
+
+// TextMerger is used to merge multiple files that are already sorted by the same columns
+type TextMerger struct {
+	files        []*DelimitedTextFile
+	cols         []*TextColumn
+	showComments bool
+	unique       bool
+}
+
+// NewTextMerger - create a new text merger. All of the files should already be sorted by cols.
+func NewTextMerger(files []*DelimitedTextFile, cols []*TextColumn) *TextMerger {
+	return &TextMerger{
+		files:        files,
+		cols:         cols,
+		showComments: false,
+		unique:       false,
+	}
+}
+
+// WithShowComments - set showing comments (only the comments from the first file are shown)
+func (tm *TextMerger) WithShowComments(b bool) *TextMerger {
+	tm.showComments = b
+	return tm
+}
+
+// WithUnique - only write the first row for each key
+func (tm *TextMerger) WithUnique(b bool) *TextMerger {
+	tm.unique = b
+	return tm
+}
+
+// WriteFile - merge the files and write the sorted rows to the given stream
+func (tm *TextMerger) WriteFile(out io.Writer) error {
+	if len(tm.files) == 0 {
+		return nil
+	}
+
+	readers := make([]RecordReader, len(tm.files))
+	defer func() {
+		for _, txt := range tm.files {
+			txt.Close()
+		}
+	}()
+
+	sorter := NewTextSorter(tm.files[0], tm.cols).
+		WithShowComments(tm.showComments).
+		WithUnique(tm.unique)
+
+	var commentsBefore []*TextRecord
+	var commentsAfter []*TextRecord
+
+	// read up to the first data line for each file, so that we have the headers
+	// (all of the headers are checked before anything is written)
+	firsts := make([]*TextRecord, len(tm.files))
+	for i, txt := range tm.files {
+		var first *TextRecord
+		for {
+			line, err := txt.ReadLine()
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if line == nil {
+				break
+			}
+			if line.Values != nil {
+				first = line
+				break
+			}
+			if i == 0 {
+				if txt.Header != nil && !txt.headerComment {
+					commentsAfter = append(commentsAfter, line)
+				} else {
+					commentsBefore = append(commentsBefore, line)
+				}
+			}
+		}
+
+		if i > 0 && !txt.noHeader && !sameHeader(tm.files[0].Header, txt.Header) {
+			return fmt.Errorf("Header mismatch: %s\n\n%s: %v\n%s: %v", txt.Filename, tm.files[0].Filename, tm.files[0].Header, txt.Filename, txt.Header)
+		}
+
+		firsts[i] = first
+		readers[i] = &peekedReader{first: first, txt: txt}
+	}
+
+	// the header comes from the first file, even if it doesn't have any rows
+	if tm.files[0].Header == nil && !hasRecord(firsts) {
+		return nil
+	}
+	if err := sorter.populateColIndex(); err != nil {
+		return err
+	}
+	firstLine := ""
+	if firsts[0] != nil {
+		firstLine = firsts[0].RawString
+	}
+	sorter.writeHeaderBlock(out, commentsBefore, commentsAfter, firstLine)
+
+	return sorter.Merge(out, readers)
+}
+
+// hasRecord - is any of the records not nil?
+func hasRecord(records []*TextRecord) bool {
+	for _, rec := range records {
+		if rec != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func sameHeader(one []string, two []string) bool {
+	if len(one) != len(two) {
+		return false
+	}
+	for i := range one {
+		if one[i] != two[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// peekedReader - a reader that returns a record that was already read before
+// continuing with the rest of the file
+type peekedReader struct {
+	first *TextRecord
+	txt   *DelimitedTextFile
+}
+
+func (rd *peekedReader) ReadLine() (*TextRecord, error) {
+	if rd.first != nil {
+		rec := rd.first
+		rd.first = nil
+		return rec, nil
+	}
+	return rd.txt.ReadLine()
+}
+
+func (rd *peekedReader) Close() {
+	rd.txt.Close()
+}
@@ -0,0 +1,84 @@
+package textfile_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mbreese/tabl/textfile"
+)
+
+func TestMerge(t *testing.T) {
+	files := []*textfile.DelimitedTextFile{
+		textfile.NewTabFile("testdata/merge1.txt"),
+		textfile.NewTabFile("testdata/merge2.txt"),
+	}
+	cols := []*textfile.TextColumn{
+		textfile.NewNamedColumn("chrom"),
+		textfile.NewNamedColumn("pos").AsNumber(),
+	}
+
+	var buf bytes.Buffer
+	err := textfile.NewTextMerger(files, cols).
+		WithShowComments(true).
+		WriteFile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "# shard one\n" +
+		"chrom\tpos\tname\n" +
+		"A\t1\tone\n" +
+		"A\t3\tthree\n" +
+		"A\t5\tfive\n" +
+		"A\t5\tfive-b\n" +
+		"B\t2\ttwo\n" +
+		"C\t1\tc-one\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected merge output:\n%s", buf.String())
+	}
+}
+
+func TestMergeHeaderMismatch(t *testing.T) {
+	files := []*textfile.DelimitedTextFile{
+		textfile.NewTabFile("testdata/merge1.txt"),
+		textfile.NewTabFile("testdata/merge_bad.txt"),
+	}
+	cols := []*textfile.TextColumn{textfile.NewNamedColumn("chrom")}
+
+	var buf bytes.Buffer
+	err := textfile.NewTextMerger(files, cols).WriteFile(&buf)
+	if err == nil {
+		t.Error("Expected a header mismatch error")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output with a header mismatch:\n%s", buf.String())
+	}
+}
+
+func TestMergeEmptyFirst(t *testing.T) {
+	files := []*textfile.DelimitedTextFile{
+		textfile.NewTabFile("testdata/merge_empty.txt"),
+		textfile.NewTabFile("testdata/merge2.txt"),
+		textfile.NewTabFile("testdata/merge1.txt"),
+	}
+	cols := []*textfile.TextColumn{textfile.NewNamedColumn("chrom")}
+
+	var buf bytes.Buffer
+	err := textfile.NewTextMerger(files, cols).WriteFile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "chrom\tpos\tname\n" +
+		"A\t3\tthree\n" +
+		"A\t5\tfive-b\n" +
+		"A\t1\tone\n" +
+		"A\t5\tfive\n" +
+		"B\t2\ttwo\n" +
+		"C\t1\tc-one\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected merge output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,479 @@
+package textfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MultiTextFile reads a list of delimited text files as if they were one file. The
+// header is only returned once, so all of the files should have the same header. Files
+// with different headers can be combined by matching columns by name (reorder), or by
+// using the union or intersection of the columns.
+type MultiTextFile struct {
+	txt            *DelimitedTextFile
+	files          []*DelimitedTextFile
+	union          bool
+	intersect      bool
+	reorder        bool
+	sourceCol      string
+	introduced     [][]string
+	dropped        [][]string
+	header         []string
+	colMap         [][]int
+	cur            int
+	inData         bool
+	lineOffset     int
+	dataLineOffset int
+}
+
+// NewMultiFile - combine multiple files. The delimiter and header options are taken from the first file.
+func NewMultiFile(files []*DelimitedTextFile) *MultiTextFile {
+	return &MultiTextFile{
+		files:     files,
+		union:     false,
+		sourceCol: "",
+	}
+}
+
+// WithUnionHeader - allow files with different headers, the combined header is the union
+// of all columns (matched by name). Missing values are left blank.
+func (mf *MultiTextFile) WithUnionHeader(b bool) *MultiTextFile {
+	mf.union = b
+	return mf
+}
+
+// WithIntersectHeader - allow files with different headers, the combined header only has
+// the columns that are in all files (matched by name). Other columns are dropped.
+func (mf *MultiTextFile) WithIntersectHeader(b bool) *MultiTextFile {
+	mf.intersect = b
+	return mf
+}
+
+// WithReorderColumns - allow files with the same columns in a different order. Columns are
+// matched by name and re-ordered to match the first file.
+func (mf *MultiTextFile) WithReorderColumns(b bool) *MultiTextFile {
+	mf.reorder = b
+	return mf
+}
+
+// WithSourceColumn - add a column with this name that has the filename for each row
+func (mf *MultiTextFile) WithSourceColumn(name string) *MultiTextFile {
+	mf.sourceCol = name
+	return mf
+}
+
+// Open - check the headers for all of the files and return the combined file. This can then
+// be used anywhere a DelimitedTextFile is used.
+func (mf *MultiTextFile) Open() (*DelimitedTextFile, error) {
+	if len(mf.files) == 0 {
+		return nil, errors.New("Missing files")
+	}
+
+	for _, f := range mf.files {
+		if f.Filename == "-" && len(mf.files) > 1 {
+			return nil, errors.New("stdin (-) can't be combined with other files")
+		}
+	}
+
+	first := mf.files[0]
+
+	mf.txt = first.Clone(first.Filename).
+		WithNoHeader(first.noHeader).
+		WithHeaderComment(first.headerComment)
+	mf.txt.multi = mf
+
+	if len(mf.files) == 1 {
+		// nothing to match, so the header is set when it is read (this also works for stdin)
+		mf.colMap = make([][]int, 1)
+		return mf.txt, nil
+	}
+
+	headers := make([][]string, len(mf.files))
+	for i, f := range mf.files {
+		header, err := readHeader(f)
+		if err != nil {
+			return nil, err
+		}
+		headers[i] = header
+	}
+
+	if first.noHeader {
+		// no header names to match, so just use the widest file
+		for _, h := range headers {
+			if len(h) > len(mf.header) {
+				mf.header = h
+			}
+		}
+		mf.colMap = make([][]int, len(mf.files))
+	} else if mf.union || mf.intersect || mf.reorder {
+		union, colMap := unionHeaders(headers)
+		mf.setIntroduced(headers, union, colMap)
+
+		if mf.intersect {
+			mf.header, mf.colMap = intersectHeaders(len(headers), union, colMap)
+			mf.setDropped(headers)
+		} else if mf.union {
+			mf.header, mf.colMap = union, colMap
+		} else {
+			for i := 1; i < len(headers); i++ {
+				if len(headers[i]) != len(union) || len(headers[0]) != len(union) {
+					return nil, fmt.Errorf("Header mismatch: %s (the columns are different, not just reordered)\n\n%s: %v\n%s: %v", mf.files[i].Filename, first.Filename, headers[0], mf.files[i].Filename, headers[i])
+				}
+			}
+			mf.header, mf.colMap = union, colMap
+		}
+	} else {
+		mf.header = headers[0]
+		for i := 1; i < len(headers); i++ {
+			if !sameHeader(headers[0], headers[i]) {
+				return nil, fmt.Errorf("Header mismatch: %s\n\n%s: %v\n%s: %v", mf.files[i].Filename, first.Filename, headers[0], mf.files[i].Filename, headers[i])
+			}
+		}
+		mf.colMap = make([][]int, len(mf.files))
+	}
+
+	if mf.sourceCol != "" {
+		mf.header = append(mf.header, mf.sourceCol)
+	}
+
+	return mf.txt, nil
+}
+
+// readHeader - find the header for a file by reading up to the first data line
+func readHeader(f *DelimitedTextFile) ([]string, error) {
+	txt := f.Clone(f.Filename).
+		WithNoHeader(f.noHeader).
+		WithHeaderComment(f.headerComment)
+	defer txt.Close()
+
+	for {
+		line, err := txt.ReadLine()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line == nil || line.Values != nil {
+			break
+		}
+	}
+	return txt.Header, nil
+}
+
+// unionHeaders - merge headers by name (in the order they are first seen), returning the
+// combined header and the index of each file's columns in the combined header.
+func unionHeaders(headers [][]string) ([]string, [][]int) {
+	var union []string
+	colMap := make([][]int, len(headers))
+
+	for i, header := range headers {
+		colMap[i] = make([]int, len(header))
+		seen := make(map[string]int)
+		for j, name := range header {
+			// duplicate names are matched to the same occurrence in the other files
+			n := seen[name]
+			seen[name]++
+
+			idx := -1
+			k := 0
+			for u, v := range union {
+				if v == name {
+					if k == n {
+						idx = u
+						break
+					}
+					k++
+				}
+			}
+			if idx == -1 {
+				union = append(union, name)
+				idx = len(union) - 1
+			}
+			colMap[i][j] = idx
+		}
+	}
+
+	return union, removeIdentity(union, colMap)
+}
+
+// intersectHeaders - keep only the columns from the union that are in all of the files.
+// Columns that are dropped have an index of -1.
+func intersectHeaders(fileCount int, union []string, colMap [][]int) ([]string, [][]int) {
+	counts := make([]int, len(union))
+	for i := 0; i < fileCount; i++ {
+		if colMap[i] == nil {
+			for u := range counts {
+				counts[u]++
+			}
+			continue
+		}
+		for _, idx := range colMap[i] {
+			counts[idx]++
+		}
+	}
+
+	var header []string
+	newIdx := make([]int, len(union))
+	for u, name := range union {
+		if counts[u] == fileCount {
+			header = append(header, name)
+			newIdx[u] = len(header) - 1
+		} else {
+			newIdx[u] = -1
+		}
+	}
+
+	newMap := make([][]int, fileCount)
+	for i := 0; i < fileCount; i++ {
+		if colMap[i] == nil {
+			newMap[i] = make([]int, len(union))
+			for u := range union {
+				newMap[i][u] = newIdx[u]
+			}
+		} else {
+			newMap[i] = make([]int, len(colMap[i]))
+			for j, idx := range colMap[i] {
+				newMap[i][j] = newIdx[idx]
+			}
+		}
+	}
+
+	return header, removeIdentity(header, newMap)
+}
+
+// removeIdentity - files with the same columns (in the same order) don't need to be re-mapped
+func removeIdentity(header []string, colMap [][]int) [][]int {
+	for i, m := range colMap {
+		identity := len(m) == len(header)
+		for j := 0; identity && j < len(m); j++ {
+			identity = m[j] == j
+		}
+		if identity {
+			colMap[i] = nil
+		}
+	}
+	return colMap
+}
+
+// setIntroduced - track which file first had each column
+func (mf *MultiTextFile) setIntroduced(headers [][]string, union []string, colMap [][]int) {
+	mf.introduced = make([][]string, len(headers))
+	seen := make([]bool, len(union))
+	for i := range headers {
+		m := colMap[i]
+		for j := range headers[i] {
+			idx := j
+			if m != nil {
+				idx = m[j]
+			}
+			if !seen[idx] {
+				seen[idx] = true
+				mf.introduced[i] = append(mf.introduced[i], union[idx])
+			}
+		}
+	}
+}
+
+// setDropped - track which columns from each file aren't in the combined header
+func (mf *MultiTextFile) setDropped(headers [][]string) {
+	mf.dropped = make([][]string, len(headers))
+	for i := range headers {
+		if mf.colMap[i] == nil {
+			continue
+		}
+		for j, idx := range mf.colMap[i] {
+			if idx == -1 {
+				mf.dropped[i] = append(mf.dropped[i], headers[i][j])
+			}
+		}
+	}
+}
+
+// reopen - return a new combined file that reads all of the files from the start
+func (mf *MultiTextFile) reopen() (*DelimitedTextFile, error) {
+	files := make([]*DelimitedTextFile, len(mf.files))
+	for i, f := range mf.files {
+		if f.Filename == "-" {
+			return nil, errors.New("stdin can't be re-read")
+		}
+		files[i] = f.Clone(f.Filename).
+			WithNoHeader(f.noHeader).
+			WithHeaderComment(f.headerComment)
+	}
+
+	return NewMultiFile(files).
+		WithUnionHeader(mf.union).
+		WithIntersectHeader(mf.intersect).
+		WithReorderColumns(mf.reorder).
+		WithSourceColumn(mf.sourceCol).
+		Open()
+}
+
+// WriteHeaderReport - write which file introduced each column (and which columns were
+// dropped from each file, for an intersection)
+func (mf *MultiTextFile) WriteHeaderReport(out io.Writer) {
+	kept := make(map[string]bool)
+	for _, name := range mf.header {
+		kept[name] = true
+	}
+
+	for i, f := range mf.files {
+		if i < len(mf.introduced) {
+			// only report the columns that made it into the combined header
+			var added []string
+			for _, name := range mf.introduced[i] {
+				if kept[name] {
+					added = append(added, name)
+				}
+			}
+			if len(added) > 0 {
+				fmt.Fprintf(out, "%s: added %s\n", f.Filename, strings.Join(added, ", "))
+			}
+		}
+		if i < len(mf.dropped) && len(mf.dropped[i]) > 0 {
+			fmt.Fprintf(out, "%s: dropped %s\n", f.Filename, strings.Join(mf.dropped[i], ", "))
+		}
+	}
+}
+
+// readLine - read the next line from the current file, moving to the next file as needed
+func (mf *MultiTextFile) readLine() (*TextRecord, error) {
+	for mf.cur < len(mf.files) {
+		f := mf.files[mf.cur]
+		line, err := f.ReadLine()
+
+		if line == nil {
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			// done with this file (if the first file doesn't have any rows, we still need its header)
+			if mf.cur == 0 && !mf.inData {
+				mf.setHeader(f)
+				mf.setRawHeader(f, "")
+			}
+			mf.lineOffset += f.curLineNum
+			mf.dataLineOffset += f.curDataLineNum
+			f.Close()
+			mf.cur++
+			mf.inData = false
+			continue
+		}
+
+		if mf.cur == 0 {
+			mf.setHeader(f)
+		}
+
+		if line.Values == nil {
+			// the first file's header block is kept, but the others are skipped
+			if mf.cur > 0 && !mf.inData {
+				continue
+			}
+			if mf.cur == 0 && !mf.inData && mf.txt.headerComment {
+				mf.txt.lastComment = line.RawString
+			}
+		} else {
+			if mf.cur == 0 && !mf.inData {
+				mf.setRawHeader(f, line.RawString)
+			}
+			mf.inData = true
+			if mf.colMap[mf.cur] != nil || mf.sourceCol != "" {
+				line.Values = mf.remap(line.Values)
+				line.RawString = mf.txt.joinLine(line.Values) + lineEnding(line.RawString)
+			}
+			if len(mf.txt.Header) < len(line.Values) {
+				newHeader := make([]string, len(line.Values))
+				if mf.txt.noHeader {
+					for i := 0; i < len(newHeader); i++ {
+						newHeader[i] = fmt.Sprintf("col%d", (i + 1))
+					}
+				}
+				copy(newHeader, mf.txt.Header)
+				mf.txt.Header = newHeader
+			}
+			line.DataLineNum += mf.dataLineOffset
+			mf.txt.curDataLineNum = line.DataLineNum
+		}
+
+		line.LineNum += mf.lineOffset
+		line.parent = mf.txt
+		mf.txt.curLineNum = line.LineNum
+		mf.txt.isEOF = mf.cur == len(mf.files)-1 && f.isEOF
+
+		return line, nil
+	}
+
+	mf.txt.isEOF = true
+	return nil, io.EOF
+}
+
+// setHeader - once the first file's header has been read, set the combined header
+func (mf *MultiTextFile) setHeader(f *DelimitedTextFile) {
+	if mf.txt.Header != nil || f.Header == nil {
+		return
+	}
+	if mf.header == nil {
+		mf.header = make([]string, len(f.Header))
+		copy(mf.header, f.Header)
+		if mf.sourceCol != "" {
+			mf.header = append(mf.header, mf.sourceCol)
+		}
+	}
+	mf.txt.Header = make([]string, len(mf.header))
+	copy(mf.txt.Header, mf.header)
+}
+
+// setRawHeader - set the raw header line for the combined file. If the combined header
+// is different than the first file's header, the header line is re-written.
+func (mf *MultiTextFile) setRawHeader(f *DelimitedTextFile, firstLine string) {
+	if mf.txt.Header == nil {
+		return
+	}
+	if !sameHeader(f.Header, mf.txt.Header) {
+		eol := lineEnding(f.rawHeaderLine)
+		if eol == "" {
+			eol = lineEnding(firstLine)
+		}
+		if mf.txt.headerComment {
+			mf.txt.lastComment = string(mf.txt.Comment) + mf.txt.joinLine(mf.txt.Header) + eol
+		} else if !mf.txt.noHeader {
+			mf.txt.rawHeaderLine = mf.txt.joinLine(mf.txt.Header) + eol
+		}
+	} else {
+		mf.txt.rawHeaderLine = f.rawHeaderLine
+	}
+}
+
+// remap - move the values for a row to match the combined header (and add the source column)
+func (mf *MultiTextFile) remap(values []string) []string {
+	colMap := mf.colMap[mf.cur]
+
+	width := len(mf.header)
+	if mf.sourceCol != "" {
+		width--
+	}
+
+	// extra values (past the end of the header) go at the end of the row
+	ret := make([]string, width)
+	var extra []string
+
+	if colMap == nil {
+		copy(ret, values)
+		if len(values) > width {
+			extra = values[width:]
+		}
+	} else {
+		for j, v := range values {
+			if j < len(colMap) && colMap[j] >= 0 {
+				ret[colMap[j]] = v
+			}
+		}
+		if len(values) > len(colMap) {
+			extra = values[len(colMap):]
+		}
+	}
+
+	if mf.sourceCol != "" {
+		ret = append(ret, mf.files[mf.cur].Filename)
+	}
+	return append(ret, extra...)
+}
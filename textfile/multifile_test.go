@@ -0,0 +1,91 @@
+package textfile_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mbreese/tabl/textfile"
+)
+
+func TestMultiFile(t *testing.T) {
+	txt, err := textfile.NewMultiFile([]*textfile.DelimitedTextFile{
+		textfile.NewTabFile("testdata/merge1.txt"),
+		textfile.NewTabFile("testdata/merge2.txt"),
+	}).Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	comments := 0
+	last := 0
+	for {
+		line, err := txt.ReadLine()
+		if line == nil || err != nil {
+			break
+		}
+		if line.Values == nil {
+			comments++
+			continue
+		}
+		count++
+		if line.DataLineNum != last+1 {
+			t.Errorf("Expected data line %d, got %d", last+1, line.DataLineNum)
+		}
+		last = line.DataLineNum
+	}
+	txt.Close()
+
+	if count != 6 {
+		t.Errorf("Expected 6 rows, got %d", count)
+	}
+	if comments != 1 {
+		t.Errorf("Expected only the first file's comment, got %d comments", comments)
+	}
+	if len(txt.Header) != 3 || txt.Header[0] != "chrom" {
+		t.Errorf("Unexpected header: %v", txt.Header)
+	}
+}
+
+func TestMultiFileHeaderMismatch(t *testing.T) {
+	_, err := textfile.NewMultiFile([]*textfile.DelimitedTextFile{
+		textfile.NewTabFile("testdata/merge1.txt"),
+		textfile.NewTabFile("testdata/merge_bad.txt"),
+	}).Open()
+	if err == nil {
+		t.Error("Expected a header mismatch error")
+	}
+}
+
+func TestMultiFileUnion(t *testing.T) {
+	txt, err := textfile.NewMultiFile([]*textfile.DelimitedTextFile{
+		textfile.NewTabFile("testdata/merge1.txt"),
+		textfile.NewTabFile("testdata/merge_bad.txt"),
+	}).WithUnionHeader(true).WithSourceColumn("source").Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cols := []*textfile.TextColumn{
+		textfile.NewNamedColumn("chrom"),
+		textfile.NewNamedColumn("pos"),
+		textfile.NewNamedColumn("start"),
+		textfile.NewNamedColumn("source"),
+	}
+
+	var buf bytes.Buffer
+	err = textfile.NewTextExporter(txt, cols).WriteFile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "chrom\tpos\tstart\tsource\n" +
+		"A\t1\t\ttestdata/merge1.txt\n" +
+		"A\t5\t\ttestdata/merge1.txt\n" +
+		"B\t2\t\ttestdata/merge1.txt\n" +
+		"A\t\t1\ttestdata/merge_bad.txt\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected union output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,150 @@
+package textfile
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// NumberFormat is used to format numeric values for display. The values themselves are not changed.
+type NumberFormat struct {
+	decimals  int
+	sigDigits int
+	thousands bool
+	sciAbove  float64
+	sciBelow  float64
+}
+
+// NewNumberFormat - create a new number format (by default, values are shown as-is)
+func NewNumberFormat() *NumberFormat {
+	return &NumberFormat{
+		decimals:  -1,
+		sigDigits: 0,
+		thousands: false,
+		sciAbove:  0,
+		sciBelow:  0,
+	}
+}
+
+// WithDecimals - show numbers with a fixed number of decimal places (-1 to show as-is)
+func (nf *NumberFormat) WithDecimals(i int) *NumberFormat {
+	nf.decimals = i
+	return nf
+}
+
+// WithSigDigits - show numbers rounded to a number of significant digits (0 to show as-is)
+func (nf *NumberFormat) WithSigDigits(i int) *NumberFormat {
+	nf.sigDigits = i
+	return nf
+}
+
+// WithThousands - set using a thousands separator (,)
+func (nf *NumberFormat) WithThousands(b bool) *NumberFormat {
+	nf.thousands = b
+	return nf
+}
+
+// WithSciAbove - show numbers with an absolute value at or above this in scientific notation (0 to disable)
+func (nf *NumberFormat) WithSciAbove(f float64) *NumberFormat {
+	nf.sciAbove = f
+	return nf
+}
+
+// WithSciBelow - show non-zero numbers with an absolute value below this in scientific notation (0 to disable)
+func (nf *NumberFormat) WithSciBelow(f float64) *NumberFormat {
+	nf.sciBelow = f
+	return nf
+}
+
+// isNumeric - is this value a number?
+func isNumeric(v string) bool {
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
+
+// isMissingValue - is this value empty (or a common placeholder for a missing number)?
+func isMissingValue(v string) bool {
+	switch v {
+	case "", ".", "NA", "N/A", "NaN", "nan", "null", "-":
+		return true
+	}
+	return false
+}
+
+// Format - format a value for display. Values that aren't numbers are returned as-is.
+func (nf *NumberFormat) Format(v string) string {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return v
+	}
+
+	abs := math.Abs(f)
+	if (nf.sciAbove > 0 && abs >= nf.sciAbove) || (nf.sciBelow > 0 && abs > 0 && abs < nf.sciBelow) {
+		prec := -1
+		if nf.decimals >= 0 {
+			prec = nf.decimals
+		} else if nf.sigDigits > 0 {
+			prec = nf.sigDigits - 1
+		}
+		return strconv.FormatFloat(f, 'e', prec, 64)
+	}
+
+	s := v
+	if nf.sigDigits > 0 {
+		s = formatSigDigits(f, nf.sigDigits)
+	} else if nf.decimals >= 0 {
+		s = strconv.FormatFloat(f, 'f', nf.decimals, 64)
+	}
+
+	if nf.thousands {
+		s = addThousands(s)
+	}
+	return s
+}
+
+// formatSigDigits - format a number (without an exponent) rounded to a number of significant digits
+func formatSigDigits(f float64, digits int) string {
+	if f == 0 {
+		return strconv.FormatFloat(f, 'f', digits-1, 64)
+	}
+
+	// round first, so that the exponent is correct for values like 9.99 -> 10.0
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(f, 'e', digits-1, 64), 64)
+	exp := int(math.Floor(math.Log10(math.Abs(rounded))))
+
+	prec := digits - 1 - exp
+	if prec < 0 {
+		prec = 0
+	}
+	return strconv.FormatFloat(rounded, 'f', prec, 64)
+}
+
+// addThousands - add a thousands separator to the integer part of a formatted number
+func addThousands(s string) string {
+	if strings.ContainsAny(s, "eE") {
+		return s
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign = s[:1]
+		s = s[1:]
+	}
+
+	intPart := s
+	rest := ""
+	if idx := strings.Index(s, "."); idx > -1 {
+		intPart = s[:idx]
+		rest = s[idx:]
+	}
+
+	var sb strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteRune(',')
+		}
+		sb.WriteRune(r)
+	}
+
+	return sign + sb.String() + rest
+}
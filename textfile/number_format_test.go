@@ -0,0 +1,36 @@
+package textfile_test
+
+import (
+	"testing"
+
+	"github.com/mbreese/tabl/textfile"
+)
+
+func TestNumberFormat(t *testing.T) {
+	tests := []struct {
+		nf       *textfile.NumberFormat
+		value    string
+		expected string
+	}{
+		{textfile.NewNumberFormat(), "0.000012345678", "0.000012345678"},
+		{textfile.NewNumberFormat(), "foo", "foo"},
+		{textfile.NewNumberFormat().WithDecimals(2), "3.14159", "3.14"},
+		{textfile.NewNumberFormat().WithDecimals(2), "NA", "NA"},
+		{textfile.NewNumberFormat().WithSigDigits(3), "0.000012345678", "0.0000123"},
+		{textfile.NewNumberFormat().WithSigDigits(3), "9.999", "10.0"},
+		{textfile.NewNumberFormat().WithSigDigits(2), "123456789", "120000000"},
+		{textfile.NewNumberFormat().WithThousands(true), "123456789", "123,456,789"},
+		{textfile.NewNumberFormat().WithThousands(true), "-1234.5678", "-1,234.5678"},
+		{textfile.NewNumberFormat().WithThousands(true), "123", "123"},
+		{textfile.NewNumberFormat().WithSciBelow(0.001), "0.000012345678", "1.2345678e-05"},
+		{textfile.NewNumberFormat().WithSciBelow(0.001), "0", "0"},
+		{textfile.NewNumberFormat().WithSciAbove(1e6).WithSigDigits(3), "123456789", "1.23e+08"},
+		{textfile.NewNumberFormat().WithSciAbove(1e6).WithDecimals(1), "123456789", "1.2e+08"},
+	}
+
+	for _, test := range tests {
+		if v := test.nf.Format(test.value); v != test.expected {
+			t.Errorf("Format(%s): expected %s, got %s", test.value, test.expected, v)
+		}
+	}
+}
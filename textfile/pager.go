@@ -2,8 +2,10 @@ package textfile
 
 import (
 	"container/list"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 
 	ui "github.com/gizak/termui/v3"
@@ -16,23 +18,49 @@ const maxLines = 20000
 
 // TextPager is a viewer for tab-delimited data, it handles formatting and showing the data on a stream
 type TextPager struct {
-	txt           *DelimitedTextFile
-	showComments  bool
-	showLineNum   bool
-	minWidth      int
-	maxWidth      int
-	colNames      []string
-	colWidth      []int
-	lines         *list.List
-	topRow        *list.Element
-	activeRow     int
-	leftCol       int
-	visibleRows   int
-	visibleCols   int
-	colSticky     []bool
-	colSelectMode bool
-	activeCol     int
-	searchQuery   string
+	txt            *DelimitedTextFile
+	showComments   bool
+	showLineNum    bool
+	minWidth       int
+	maxWidth       int
+	colNames       []string
+	colWidth       []int
+	lines          *list.List
+	topRow         *list.Element
+	activeRow      int
+	leftCol        int
+	visibleRows    int
+	visibleCols    int
+	colSticky      []bool
+	colHidden      []bool
+	colOrder       []int
+	colSelectMode  bool
+	activeCol      int
+	shownCols      []int
+	search         *pagerSearch
+	searchBack     bool
+	caseMode       int
+	wholeCell      bool
+	matchRow       *list.Element
+	matchCol       int
+	evicted        bool
+	canReread      bool
+	firstLine      int
+	marks          map[int]bool
+	markedRecords  map[int]*TextRecord
+	totalLines     int
+	count          string
+	filters        []*pagerFilter
+	lnWidth        int
+	sortCol        int
+	sortMode       int
+	source         *DelimitedTextFile
+	sortFile       string
+	commentsBefore []*TextRecord
+	commentsAfter  []*TextRecord
+	copyCmd        string
+	tty            string
+	counts         *matchCounts
 }
 
 // NewTextPager - create a new text viewer
@@ -53,6 +81,11 @@ func NewTextPager(f *DelimitedTextFile) *TextPager {
 		colSticky:     nil,
 		colSelectMode: false,
 		activeCol:     0,
+		caseMode:      caseSmart,
+		wholeCell:     false,
+		marks:         make(map[int]bool),
+		markedRecords: make(map[int]*TextRecord),
+		tty:           "/dev/tty",
 	}
 }
 
@@ -62,6 +95,12 @@ func (tv *TextPager) WithShowLineNum(b bool) *TextPager {
 	return tv
 }
 
+// WithCopyCommand - set a command to copy text to the clipboard (if OSC 52 can't be written to the terminal)
+func (tv *TextPager) WithCopyCommand(cmd string) *TextPager {
+	tv.copyCmd = cmd
+	return tv
+}
+
 // WithMinWidth - set min column width
 func (tv *TextPager) WithMinWidth(i int) *TextPager {
 	tv.minWidth = i
@@ -82,15 +121,29 @@ func (tv *TextPager) load() {
 	// headerIdx := -1
 	// we will need to auto-determine the column widths
 
+	// if we can, keep checkpoints so that lines can be re-read after they are removed from memory
+	tv.canReread = tv.txt.withIndex()
+
 	for i := 0; i < linesForEstimation; {
 		line, err = tv.txt.ReadLine()
 		if err != nil {
 			// fmt.Printf("Got an err: %s, (line: %v)\n", err, line)
+			if tv.txt.isEOF {
+				tv.totalLines = tv.txt.curDataLineNum
+			}
 			break
 		}
 		// fmt.Printf("Got an line: %v\n", line)
 
 		if line.Values == nil {
+			// keep the comments before the first row (for saving), like TextSorter
+			if tv.lines.Len() == 0 && tv.source == nil {
+				if tv.txt.Header != nil && !tv.txt.headerComment {
+					tv.commentsAfter = append(tv.commentsAfter, line)
+				} else {
+					tv.commentsBefore = append(tv.commentsBefore, line)
+				}
+			}
 			continue
 		}
 		// only incr here to avoid reading headers
@@ -103,42 +156,30 @@ func (tv *TextPager) load() {
 
 			tv.colWidth = make([]int, len(tv.txt.Header))
 			tv.colSticky = make([]bool, len(tv.txt.Header))
+			tv.colHidden = make([]bool, len(tv.txt.Header))
+			tv.colOrder = make([]int, len(tv.txt.Header))
+			for j := range tv.colOrder {
+				tv.colOrder[j] = j
+			}
 
 			for j := 0; j < len(tv.txt.Header); j++ {
-				r := []rune(tv.txt.Header[j] + "   ")
-				tv.colWidth[j] = support.MaxInt(tv.minWidth, tv.colWidth[j], len(r))
+				tv.colWidth[j] = support.MaxInt(tv.minWidth, tv.colWidth[j], support.StringWidth(tv.txt.Header[j]+"   "))
 				if tv.maxWidth > 0 {
 					tv.colWidth[j] = support.MinInt(tv.colWidth[j], tv.maxWidth)
 				}
 			}
 		}
 
-		if len(tv.colNames) < len(tv.txt.Header) {
-			tv.colNames = make([]string, len(tv.txt.Header))
-			copy(tv.colNames, tv.txt.Header)
-
-			newWidths := make([]int, len(tv.txt.Header))
-			copy(newWidths, tv.colWidth)
-			tv.colWidth = newWidths
-
-			newSticky := make([]bool, len(tv.txt.Header))
-			copy(newSticky, tv.colSticky)
-			tv.colSticky = newSticky
+		tv.growColumns(line)
 
-			for j := 0; j < len(tv.txt.Header); j++ {
-				r := []rune(tv.txt.Header[j] + "   ")
-				tv.colWidth[j] = support.MaxInt(tv.minWidth, tv.colWidth[j], len(r))
-				if tv.maxWidth > 0 {
-					tv.colWidth[j] = support.MinInt(tv.colWidth[j], tv.maxWidth)
-				}
-			}
+		if tv.lines.Len() == 0 {
+			tv.firstLine = line.fileLine()
 		}
-
+		line.Flag = tv.marks[line.LineNum]
 		tv.lines.PushBack(line)
 
 		for j := 0; j < len(line.Values); j++ {
-			r := []rune(line.Values[j] + "   ")
-			tv.colWidth[j] = support.MaxInt(tv.minWidth, tv.colWidth[j], len(r))
+			tv.colWidth[j] = support.MaxInt(tv.minWidth, tv.colWidth[j], support.StringWidth(line.Values[j]+"   "))
 			if tv.maxWidth > 0 {
 				tv.colWidth[j] = support.MinInt(tv.colWidth[j], tv.maxWidth)
 			}
@@ -165,6 +206,33 @@ func (tv *TextPager) load() {
 
 }
 
+// growColumns - add the columns for a row that is wider than the columns we've seen so far (the
+// widths are only estimated from the first lines, but any row can have more columns)
+func (tv *TextPager) growColumns(t *TextRecord) {
+	for j := len(tv.colNames); j < len(t.Values) || j < len(tv.txt.Header); j++ {
+		name := ""
+		if j < len(tv.txt.Header) {
+			name = tv.txt.Header[j]
+		} else if tv.txt.noHeader {
+			name = fmt.Sprintf("col%d", j+1)
+		}
+
+		width := support.MaxInt(tv.minWidth, support.StringWidth(name+"   "))
+		if j < len(t.Values) {
+			width = support.MaxInt(width, support.StringWidth(t.Values[j]+"   "))
+		}
+		if tv.maxWidth > 0 {
+			width = support.MinInt(width, tv.maxWidth)
+		}
+
+		tv.colNames = append(tv.colNames, name)
+		tv.colWidth = append(tv.colWidth, width)
+		tv.colSticky = append(tv.colSticky, false)
+		tv.colHidden = append(tv.colHidden, false)
+		tv.colOrder = append(tv.colOrder, j)
+	}
+}
+
 // Show - format and show the data as a table that can scroll with user-interaction
 func (tv *TextPager) Show() {
 
@@ -180,6 +248,8 @@ func (tv *TextPager) Show() {
 	// Disable mouse capture so the terminal's native text selection works.
 	tb.SetInputMode(tb.InputEsc)
 	defer ui.Close()
+	defer tv.removeSortFile()
+	defer func() { tv.txt.index.close() }()
 
 	// width, height, err := terminal.GetSize(0)
 	// if err != nil {
@@ -188,7 +258,7 @@ func (tv *TextPager) Show() {
 
 	width, height := ui.TerminalDimensions()
 
-	tbl := widgets.NewTable()
+	tbl := newPagerTable()
 	tbl.TextStyle = ui.NewStyle(ui.ColorWhite)
 	tbl.SetRect(0, 0, width, height)
 	tbl.RowSeparator = false
@@ -205,41 +275,183 @@ func (tv *TextPager) Show() {
 	p0.Border = true
 
 	p1 := widgets.NewParagraph()
-	p1.SetRect(0, 0, 50, 24)
 	p1.Border = true
-	p1.Text = `[tabl                                        help](mod:reverse)
-------------------------------------------------
+	p1.Text = `[tabl                                                  help](mod:reverse)
+----------------------------------------------------------
 q,Ctrl-C,ESC      Quit the program
-/                 Search (highlights matches)
-n                 Find next match
+H,F1              Show this help text
+/,?               Search forward/backward (regex)
+                  "col:query" searches one column
+                  ":query" searches the active one
+n,N               Find the next/previous match
+i                 Toggle smart/ignore/exact case
+w                 Toggle whole-cell matching
+&,f               Filter rows ("col>10", "col=x", "!text")
+                  (filters stack, an empty one clears)
+F                 Remove the last filter
+o                 Sort by the active column (again to go
+                  through descending, numeric, unsorted)
 m,Enter           Mark a line
 c                 Clear marked lines
-s                 Save all marked lines to 
-                  a file
-x                 Select "sticky" columns
-                  To select sticky columns, use 
-                  arrow keys and hit space to 
-                  toggle on/off.
+y                 Copy the cell, row or marked rows to
+                  the clipboard (as TSV or CSV)
+s                 Save marked (or all) rows to a file
+                  (Tab/Ctrl-R/Ctrl-V change what is saved)
+x                 Select "sticky" columns (use the
+                  arrow keys and space to toggle)
+v                 Show the active row vertically
+d                 Show the active row in a popup (with
+                  the full values, which can be copied)
+-,+               Hide the active column/show all columns
+<,>               Move the active column left/right
+[,],=             Narrow/widen/auto-fit the active column
+C                 Pick the columns to show
 
 [Navigation]
-h,left-arrow      Move left a column  
-j,down-arrow      Move down a row
-k,up-arrow        Move up a row  
-l,right-arrow     Move right a column  
-space             Move down a page
-b                 Move up a page
+h,j,k,l,arrows    Move left/down/up/right
+space,b           Move down/up a page
+Ctrl-D,Ctrl-U     Move down/up half a page
+g,G               Go to the first/last row
+:                 Go to a line (or N%)
+Ng,N%             Go to line N (or N% of the way)
+
+j,k to scroll, ESC to hide help text`
+
+	// the help text scrolls (below the title), if it doesn't fit
+	helpLines := strings.Split(p1.Text, "\n")
+	helpTop := 0
+	showHelp := func() {
+		height := support.MinInt(tv.visibleRows, len(helpLines)+2)
+		helpTop = support.MaxInt(0, support.MinInt(helpTop, len(helpLines)+2-height))
+		p1.Text = strings.Join(append(helpLines[:2:2], helpLines[2+helpTop:]...), "\n")
+		p1.SetRect(0, 0, 60, height)
+		ui.Render(p1)
+	}
+
+	p2 := newPagerList()
+	p2.SetRect(0, 0, width, height)
+	p2.SelectedRowStyle = p2.TextStyle
+
+	p3 := newPagerList()
+	p3.Title = " Columns (space to show/hide, <,> to move, q to close) "
+	p3.SetRect(0, 0, width, height)
+	p3.SelectedRowStyle = activeStyle
 
-ESC to hide help text
-`
+	p4 := newRowDetail()
 
 	state := "view"
 	query := ""
 	savePath := ""
 	saveError := ""
+	saveOpts := pagerSave{}
+	copyFormat := saveTSV
 
-	lastMatchCol := 0
+	// copyPrompt - show the prompt for what to copy
+	copyPrompt := func() {
+		p0.Text = " Copy: c=cell, r=row, m=marked rows (t=TSV/CSV: " + saveFormatNames[copyFormat] + ")"
+		ui.Render(p0)
+	}
+
+	// savePrompt - show the prompt for saving rows (with the keys to change what is saved)
+	savePrompt := func() {
+		p0.Title = " Tab: format, Ctrl-R: rows, Ctrl-V: columns "
+		p0.Text = " Save " + saveOpts.describe() + " to file: " + savePath
+		tb.SetCursor(len(p0.Text)+1, 1)
+		ui.Render(p0)
+	}
 
 	events := ui.PollEvents()
+
+	gotoQuery := ""
+	filterQuery := ""
+
+	// check for cancel events while searching (non-blocking)
+	checkCancel := func() bool {
+		select {
+		case ev := <-events:
+			return ev.ID == "<C-c>" || ev.ID == "<Escape>"
+		default:
+		}
+		return false
+	}
+
+	// jump - go to another line (which might need to read ahead), and show where we end up
+	jump := func(f func() (bool, bool)) {
+		found, cancelled := f()
+		tv.trimLines()
+		tv.updateTable(tbl)
+		ui.Render(tbl)
+		if cancelled {
+			p0.Text = " Cancelled"
+			ui.Render(p0)
+		} else if !found {
+			p0.Text = " Line not found"
+			ui.Render(p0)
+		}
+	}
+
+	// jumpPercent - go to a percentage of the way through the file. This needs the number of lines,
+	// so we may need to read to the end of the file first.
+	jumpPercent := func(pct int) {
+		progress := func(msg string) {
+			p0.Text = msg
+			ui.Render(p0)
+		}
+		if !tv.readToEnd(events, progress) {
+			tv.trimLines()
+			tv.updateTable(tbl)
+			ui.Render(tbl)
+			p0.Text = " Cancelled"
+			ui.Render(p0)
+			return
+		}
+		jump(func() (bool, bool) { return tv.gotoPercent(pct, checkCancel) })
+	}
+
+	// showMatchStatus - show the current match (counting the matches first, if needed)
+	showMatchStatus := func() {
+		if tv.needsCount() {
+			p0.Text = " Counting matches... (Ctrl-C to skip)"
+			ui.Render(p0)
+			tv.countMatches(checkCancel)
+		}
+		p0.Text = tv.matchStatus()
+		ui.Render(p0)
+	}
+
+	// applyFilters - update the rows shown after the filters have changed. If a filter was added and
+	// there aren't any rows left (or this is cancelled), the new filter is removed again.
+	applyFilters := func(added bool) {
+		p0.Text = " Filtering... (Ctrl-C to cancel)"
+		ui.Render(p0)
+
+		found, cancelled := tv.refilter(checkCancel)
+		if (cancelled || !found) && added {
+			tv.filters = tv.filters[:len(tv.filters)-1]
+			tv.refilter(func() bool { return false })
+		}
+		tv.trimLines()
+		tv.updateTable(tbl)
+		ui.Render(tbl)
+		if cancelled {
+			p0.Text = " Cancelled"
+			ui.Render(p0)
+		} else if !found {
+			p0.Text = " No matching rows"
+			ui.Render(p0)
+		}
+	}
+
+	// clearFilters - show all of the rows again
+	clearFilters := func() {
+		tv.filters = nil
+		applyFilters(false)
+		if !tv.canReread {
+			p0.Text = " Filters cleared (hidden rows from stdin can't be shown again)"
+			ui.Render(p0)
+		}
+	}
+
 	for e := range events {
 		// fmt.Printf("%v\n", e)
 		if state == "help" {
@@ -252,10 +464,16 @@ ESC to hide help text
 				tv.updateTable(tbl)
 				ui.Render(tbl)
 
-				p0.Text = " Search: " + query
+				p0.Text = tv.searchPrompt(query)
 				p0.SetRect(0, 0, tv.visibleCols, 3)
 
-				ui.Render(p1)
+				showHelp()
+			case "j", "<Down>":
+				helpTop++
+				showHelp()
+			case "k", "<Up>":
+				helpTop--
+				showHelp()
 			case "q", "<Escape>", "<Space>":
 				state = "view"
 				ui.Render(tbl)
@@ -271,82 +489,47 @@ ESC to hide help text
 				if len(query) > 0 {
 					query = query[:len(query)-1]
 				}
-				p0.Text = " Search: " + query
+				p0.Text = tv.searchPrompt(query)
 				tb.SetCursor(len(p0.Text)+1, 1)
 				ui.Render(p0)
 			case "<Enter>":
 				tb.HideCursor()
 				if query == "" {
-					tv.searchQuery = ""
+					tv.search = nil
 					state = "view"
 					tv.updateTable(tbl)
 					ui.Render(tbl)
 					break
 				}
-				found := false
-				cancelled := false
-				origTop := tv.topRow
-				origActive := tv.activeRow
-
+				search, err := newPagerSearch(query, tv.txt.Header, tv.activeColumn(), tv.caseMode, tv.wholeCell)
+				if err != nil {
+					p0.Text = " Invalid search: " + err.Error()
+					ui.Render(p0)
+					break
+				}
 				p0.Text = " Searching... (Ctrl-C to cancel)"
 				ui.Render(p0)
 
-				for el := tv.topRow; !found && el != nil; el = el.Next() {
-					// check for cancel events (non-blocking)
-					select {
-					case ev := <-events:
-						if ev.ID == "<C-c>" || ev.ID == "<Escape>" {
-							cancelled = true
-						}
-					default:
-					}
-					if cancelled {
-						break
-					}
+				// a new search starts from the active row
+				prev := tv.search
+				tv.search = search
+				tv.matchRow = nil
+				found, cancelled := tv.findMatch(tv.searchBack, checkCancel)
+				tv.trimLines()
 
-					line, ok := el.Value.(*TextRecord)
-					if !ok || line == nil || line.Values == nil {
-						continue
-					}
-					for i, v := range line.Values {
-						if el == tv.topRow && i <= lastMatchCol {
-							continue
-						}
-						if strings.Contains(v, query) {
-							found = true
-							tv.leftCol = i
-							tv.topRow = el
-							tv.activeRow = 1
-							lastMatchCol = i
-							break
-						}
-					}
-					if !found && el.Next() == nil && !tv.txt.isEOF {
-						l, err := tv.txt.ReadLine()
-						if err != nil {
-							break
-						}
-						tv.lines.PushBack(l)
-					}
-				}
 				if cancelled {
+					tv.search = prev
 					p0.Text = " Search cancelled"
 					ui.Render(p0)
-					tv.topRow = origTop
-					tv.activeRow = origActive
 				} else if !found {
+					tv.search = prev
 					p0.Text = " Not found!"
 					ui.Render(p0)
-					tv.topRow = origTop
-					tv.activeRow = origActive
 				} else {
-					for tv.lines.Len() > maxLines {
-						tv.lines.Remove(tv.lines.Front())
-					}
-					tv.searchQuery = query
 					state = "view"
 					tv.updateTable(tbl)
 					ui.Render(tbl)
+					showMatchStatus()
 				}
 
 			case "<Resize>":
@@ -358,18 +541,70 @@ ESC to hide help text
 				tv.updateTable(tbl)
 				ui.Render(tbl)
 
-				p0.Text = " Search: " + query
+				p0.Text = tv.searchPrompt(query)
 				p0.SetRect(0, 0, tv.visibleCols, 3)
 				tb.SetCursor(len(p0.Text)+1, 1)
 				ui.Render(p0)
 			default:
-				if e.ID == "<Space>" || (e.ID[0:1] != "<" && e.ID[len(e.ID)-1:] != ">") {
-					if e.ID == "<Space>" {
-						query += " "
-					} else {
-						query += e.ID
+				if text, ok := typedText(e.ID); ok {
+					query += text
+					p0.Text = tv.searchPrompt(query)
+					tb.SetCursor(len(p0.Text)+1, 1)
+					ui.Render(p0)
+				}
+			}
+		} else if state == "filter" {
+			switch e.ID {
+			case "<C-c>", "<Escape>":
+				tb.HideCursor()
+				state = "view"
+				filterQuery = ""
+				ui.Render(tbl)
+			case "<Backspace>":
+				if len(filterQuery) > 0 {
+					filterQuery = filterQuery[:len(filterQuery)-1]
+				}
+				p0.Text = " Filter: " + filterQuery
+				tb.SetCursor(len(p0.Text)+1, 1)
+				ui.Render(p0)
+			case "<Enter>":
+				if filterQuery == "" {
+					// an empty filter clears all of the filters
+					tb.HideCursor()
+					state = "view"
+					if len(tv.filters) > 0 {
+						clearFilters()
 					}
-					p0.Text = " Search: " + query
+					break
+				}
+				filter, err := newPagerFilter(filterQuery, tv.txt.Header, tv.activeColumn(), tv.caseMode, tv.wholeCell)
+				if err != nil {
+					p0.Text = " Invalid filter: " + err.Error()
+					ui.Render(p0)
+					break
+				}
+				tb.HideCursor()
+				state = "view"
+				filterQuery = ""
+				tv.filters = append(tv.filters, filter)
+				applyFilters(true)
+			case "<Resize>":
+				payload := e.Payload.(ui.Resize)
+				tbl.SetRect(0, 0, payload.Width, payload.Height)
+				tv.visibleRows = payload.Height
+				tv.visibleCols = payload.Width
+
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+
+				p0.Text = " Filter: " + filterQuery
+				p0.SetRect(0, 0, tv.visibleCols, 3)
+				tb.SetCursor(len(p0.Text)+1, 1)
+				ui.Render(p0)
+			default:
+				if text, ok := typedText(e.ID); ok {
+					filterQuery += text
+					p0.Text = " Filter: " + filterQuery
 					tb.SetCursor(len(p0.Text)+1, 1)
 					ui.Render(p0)
 				}
@@ -380,20 +615,29 @@ ESC to hide help text
 				tb.HideCursor()
 				state = "view"
 				query = ""
+				p0.Title = ""
 				ui.Render(tbl)
 			case "<Backspace>":
 				if len(savePath) > 0 {
 					savePath = savePath[:len(savePath)-1]
 				}
-				p0.Text = " Save marked rows to file: " + savePath
-				tb.SetCursor(len(p0.Text)+1, 1)
-				ui.Render(p0)
+				savePrompt()
+			case "<Tab>":
+				saveOpts.format = (saveOpts.format + 1) % len(saveFormatNames)
+				savePrompt()
+			case "<C-r>":
+				saveOpts.marked = !saveOpts.marked
+				savePrompt()
+			case "<C-v>":
+				saveOpts.shownCols = !saveOpts.shownCols
+				savePrompt()
 			case "<Enter>":
+				p0.Title = ""
 				_, err := os.Stat(savePath)
 				if os.IsNotExist(err) {
 					// save the file, it doesn't exist
 
-					err := tv.saveToFile(savePath)
+					err := tv.saveToFile(savePath, saveOpts)
 
 					if err != nil {
 						saveError = err.Error()
@@ -426,20 +670,12 @@ ESC to hide help text
 				tv.updateTable(tbl)
 				ui.Render(tbl)
 
-				p0.Text = " Save marked rows to file: " + savePath
 				p0.SetRect(0, 0, tv.visibleCols, 3)
-				tb.SetCursor(len(p0.Text)+1, 1)
-				ui.Render(p0)
+				savePrompt()
 			default:
-				if e.ID == "<Space>" || (e.ID[0:1] != "<" && e.ID[len(e.ID)-1:] != ">") {
-					if e.ID == "<Space>" {
-						savePath += " "
-					} else {
-						savePath += e.ID
-					}
-					p0.Text = " Save marked rows to file: " + savePath
-					tb.SetCursor(len(p0.Text)+1, 1)
-					ui.Render(p0)
+				if text, ok := typedText(e.ID); ok {
+					savePath += text
+					savePrompt()
 				}
 			}
 		} else if state == "save_error" {
@@ -472,12 +708,20 @@ ESC to hide help text
 				tv.updateTable(tbl)
 				ui.Render(tbl)
 			case "Y", "y":
-				tv.saveToFile(savePath)
+				err := tv.saveToFile(savePath, saveOpts)
 				tb.HideCursor()
-				state = "view"
 				savePath = ""
-				tv.updateTable(tbl)
-				ui.Render(tbl)
+				if err != nil {
+					saveError = err.Error()
+					state = "save_error"
+					p0.Text = " Error: " + saveError
+					p0.SetRect(0, 0, tv.visibleCols, 3)
+					ui.Render(p0)
+				} else {
+					state = "view"
+					tv.updateTable(tbl)
+					ui.Render(tbl)
+				}
 
 			case "<Resize>":
 				payload := e.Payload.(ui.Resize)
@@ -493,6 +737,160 @@ ESC to hide help text
 				tb.SetCursor(len(p0.Text)+1, 1)
 				ui.Render(p0)
 			}
+		} else if state == "columns" {
+			switch e.ID {
+			case "q", "<Escape>", "C":
+				state = "view"
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+			case "<C-c>":
+				return
+			case "<Resize>":
+				payload := e.Payload.(ui.Resize)
+				tbl.SetRect(0, 0, payload.Width, payload.Height)
+				p3.SetRect(0, 0, payload.Width, payload.Height)
+				tv.visibleRows = payload.Height
+				tv.visibleCols = payload.Width
+				tv.updateTable(tbl)
+				p0.SetRect(0, 0, tv.visibleCols, 3)
+				ui.Render(p3)
+			case "j", "<Down>":
+				p3.ScrollDown()
+				ui.Render(p3)
+			case "k", "<Up>":
+				p3.ScrollUp()
+				ui.Render(p3)
+			case "<Space>", "<Enter>", "x":
+				tv.toggleColumn(tv.colOrder[p3.SelectedRow])
+				tv.updateColumnList(p3)
+				ui.Render(p3)
+			case "<", ">":
+				if tv.moveColumn(tv.colOrder[p3.SelectedRow], e.ID == ">") {
+					if e.ID == ">" {
+						p3.ScrollDown()
+					} else {
+						p3.ScrollUp()
+					}
+				}
+				tv.updateColumnList(p3)
+				ui.Render(p3)
+			}
+		} else if state == "detail" {
+			switch e.ID {
+			case "q", "<Escape>", "d":
+				state = "view"
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+			case "<C-c>":
+				return
+			case "<Resize>":
+				payload := e.Payload.(ui.Resize)
+				tbl.SetRect(0, 0, payload.Width, payload.Height)
+				tv.visibleRows = payload.Height
+				tv.visibleCols = payload.Width
+				tv.updateTable(tbl)
+				p0.SetRect(0, 0, tv.visibleCols, 3)
+				tv.updateDetail(p4, "")
+				ui.Render(tbl, p4)
+			case "j", "<Down>":
+				p4.ScrollDown()
+				ui.Render(p4)
+			case "k", "<Up>":
+				p4.ScrollUp()
+				ui.Render(p4)
+			case "<Space>", "<C-d>":
+				p4.ScrollPageDown()
+				ui.Render(p4)
+			case "b", "<C-u>":
+				p4.ScrollPageUp()
+				ui.Render(p4)
+			case "g", "<Home>":
+				p4.ScrollTop()
+				ui.Render(p4)
+			case "G", "<End>":
+				p4.ScrollBottom()
+				ui.Render(p4)
+			case "n", "p":
+				// the next (or previous) row
+				if e.ID == "n" {
+					tv.moveDown()
+				} else {
+					tv.moveUp()
+				}
+				tv.updateTable(tbl)
+				tv.trimLines()
+				tv.updateDetail(p4, "")
+				ui.Render(tbl, p4)
+			case "y":
+				tv.updateDetail(p4, tv.copyField(p4))
+				ui.Render(p4)
+			}
+		} else if state == "copy" {
+			switch e.ID {
+			case "t":
+				if copyFormat == saveTSV {
+					copyFormat = saveCSV
+				} else {
+					copyFormat = saveTSV
+				}
+				copyPrompt()
+			case "c", "r", "m":
+				var msg string
+				switch e.ID {
+				case "c":
+					msg = tv.copyCell()
+				case "r":
+					msg = tv.copyRow(copyFormat)
+				default:
+					msg = tv.copyMarked(copyFormat)
+				}
+				state = "view"
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+				p0.Text = msg
+				ui.Render(p0)
+			case "<Resize>":
+				payload := e.Payload.(ui.Resize)
+				tbl.SetRect(0, 0, payload.Width, payload.Height)
+				tv.visibleRows = payload.Height
+				tv.visibleCols = payload.Width
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+				p0.SetRect(0, 0, tv.visibleCols, 3)
+				copyPrompt()
+			default:
+				state = "view"
+				ui.Render(tbl)
+			}
+		} else if state == "vertical" {
+			switch e.ID {
+			case "q", "<Escape>", "v":
+				state = "view"
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+			case "<C-c>":
+				return
+			case "<Resize>":
+				payload := e.Payload.(ui.Resize)
+				tbl.SetRect(0, 0, payload.Width, payload.Height)
+				p2.SetRect(0, 0, payload.Width, payload.Height)
+				tv.visibleRows = payload.Height
+				tv.visibleCols = payload.Width
+				tv.updateTable(tbl)
+				p0.SetRect(0, 0, tv.visibleCols, 3)
+				tv.updateVertical(p2)
+				ui.Render(p2)
+			case "j", "<Down>":
+				tv.moveDown()
+				tv.updateTable(tbl)
+				tv.updateVertical(p2)
+				ui.Render(p2)
+			case "k", "<Up>":
+				tv.moveUp()
+				tv.updateTable(tbl)
+				tv.updateVertical(p2)
+				ui.Render(p2)
+			}
 		} else if state == "select" {
 			switch e.ID {
 			case "q", "<Escape>":
@@ -514,10 +912,10 @@ ESC to hide help text
 				p0.SetRect(0, 0, tv.visibleCols, 3)
 				ui.Render(tbl)
 			case "x", "<Space>", "<Enter>":
-				j := -support.BoolSum(tv.colSticky)
+				j := -tv.stickyCount()
 				found := false
-				for i, v := range tv.colSticky {
-					if v {
+				for _, i := range tv.columns() {
+					if tv.colSticky[i] {
 						if j == tv.leftCol {
 							tv.colSticky[i] = !tv.colSticky[i]
 							found = true
@@ -528,8 +926,8 @@ ESC to hide help text
 				}
 
 				if !found {
-					for i, v := range tv.colSticky {
-						if !v {
+					for _, i := range tv.columns() {
+						if !tv.colSticky[i] {
 							if j == tv.leftCol {
 								tv.colSticky[i] = !tv.colSticky[i]
 								found = true
@@ -553,27 +951,119 @@ ESC to hide help text
 				ui.Render(tbl)
 			case "l", "<Right>":
 				tv.leftCol++
-				if tv.leftCol >= len(tv.colWidth)-support.BoolSum(tv.colSticky) {
-					tv.leftCol = len(tv.colWidth) - support.BoolSum(tv.colSticky) - 1
+				if tv.leftCol >= tv.scrollCols() {
+					tv.leftCol = tv.scrollCols() - 1
 				}
 				tv.updateTable(tbl)
 				ui.Render(tbl)
 			case "h", "<Left>":
 				tv.leftCol--
-				if tv.leftCol < -support.BoolSum(tv.colSticky) {
-					tv.leftCol = -support.BoolSum(tv.colSticky)
+				if tv.leftCol < -tv.stickyCount() {
+					tv.leftCol = -tv.stickyCount()
+				}
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+			}
+		} else if state == "goto" {
+			switch e.ID {
+			case "<C-c>", "<Escape>":
+				tb.HideCursor()
+				state = "view"
+				gotoQuery = ""
+				ui.Render(tbl)
+			case "<Backspace>":
+				if len(gotoQuery) > 0 {
+					gotoQuery = gotoQuery[:len(gotoQuery)-1]
+				}
+				p0.Text = " Go to line (or N%): " + gotoQuery
+				tb.SetCursor(len(p0.Text)+1, 1)
+				ui.Render(p0)
+			case "<Enter>":
+				if strings.HasSuffix(gotoQuery, "%") {
+					pct, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(gotoQuery, "%")))
+					if err != nil {
+						p0.Text = " Invalid percentage: " + gotoQuery
+						ui.Render(p0)
+						break
+					}
+					tb.HideCursor()
+					state = "view"
+					jumpPercent(pct)
+				} else {
+					n, err := strconv.Atoi(strings.TrimSpace(gotoQuery))
+					if err != nil {
+						p0.Text = " Invalid line number: " + gotoQuery
+						ui.Render(p0)
+						break
+					}
+					tb.HideCursor()
+					state = "view"
+					jump(func() (bool, bool) { return tv.gotoLine(n, checkCancel) })
 				}
+				gotoQuery = ""
+			case "<Resize>":
+				payload := e.Payload.(ui.Resize)
+				tbl.SetRect(0, 0, payload.Width, payload.Height)
+				tv.visibleRows = payload.Height
+				tv.visibleCols = payload.Width
+
 				tv.updateTable(tbl)
 				ui.Render(tbl)
+
+				p0.Text = " Go to line (or N%): " + gotoQuery
+				p0.SetRect(0, 0, tv.visibleCols, 3)
+				tb.SetCursor(len(p0.Text)+1, 1)
+				ui.Render(p0)
+			default:
+				if text, ok := typedText(e.ID); ok {
+					gotoQuery += text
+					p0.Text = " Go to line (or N%): " + gotoQuery
+					tb.SetCursor(len(p0.Text)+1, 1)
+					ui.Render(p0)
+				}
 			}
 		} else {
+			// a number typed before a key (like "50%" or "123g")
+			num := tv.count
+			tv.count = ""
+
 			switch e.ID {
+			case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+				tv.count = num + e.ID
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+			case "g", "G":
+				// first (or last) row, or the row for the number typed
+				if num != "" {
+					n, _ := strconv.Atoi(num)
+					jump(func() (bool, bool) { return tv.gotoLine(n, checkCancel) })
+				} else if e.ID == "g" {
+					jump(func() (bool, bool) { return tv.gotoPercent(0, checkCancel) })
+				} else {
+					jumpPercent(100)
+				}
+			case "%":
+				if num != "" {
+					pct, _ := strconv.Atoi(num)
+					jumpPercent(pct)
+				}
+			case ":":
+				state = "goto"
+				p0.Text = " Go to line (or N%): " + gotoQuery
+				tb.SetCursor(len(p0.Text)+1, 1)
+				ui.Render(p0)
+			case "<C-d>", "<C-u>":
+				// half a page down (or up)
+				tv.halfPage(e.ID == "<C-d>")
+				tv.updateTable(tbl)
+				ui.Render(tbl)
 			case "q", "<Escape>", "<C-c>":
 				// quit
 				return
-			case "?":
+			case "H", "<F1>":
 				state = "help"
-				ui.Render(p1)
+				helpTop = 0
+				showHelp()
 			case "<Resize>":
 				payload := e.Payload.(ui.Resize)
 				tbl.SetRect(0, 0, payload.Width, payload.Height)
@@ -590,11 +1080,9 @@ ESC to hide help text
 					if e.Next() == nil && !tv.txt.isEOF {
 						// need to load more lines!
 						// qfmt.Fprintln(os.Stderr, "Loading more lines")
-						l, err := tv.txt.ReadLine()
-						if err != nil {
+						if !tv.pushLine() {
 							break
 						}
-						tv.lines.PushBack(l)
 					}
 					if e.Next() != nil {
 						e = e.Next()
@@ -604,6 +1092,7 @@ ESC to hide help text
 				tv.topRow = e
 				tv.updateTable(tbl)
 				ui.Render(tbl)
+				tv.trimLines()
 			case "c":
 				tv.clearMarked()
 				tv.updateTable(tbl)
@@ -635,15 +1124,13 @@ ESC to hide help text
 
 				tv.updateTable(tbl)
 				ui.Render(tbl)
-				for tv.lines.Len() > maxLines {
-					tv.lines.Remove(tv.lines.Front())
-				}
+				tv.trimLines()
 
 			case "b":
 				// back a page
 				e := tv.topRow
 				i := 0
-				for i = 0; e.Prev() != nil && i < tv.visibleRows-3; i++ {
+				for i = 0; (e.Prev() != nil || tv.loadBefore()) && i < tv.visibleRows-3; i++ {
 					if e.Prev() != nil {
 						e = e.Prev()
 					}
@@ -654,43 +1141,34 @@ ESC to hide help text
 				ui.Render(tbl)
 			case "j", "<Down>":
 				// down a line
-				tv.activeRow++
-
-				maxActiveRow := 0
-
-				for last := tv.topRow; last.Next() != nil && maxActiveRow < tv.visibleRows-3; last = last.Next() {
-					maxActiveRow++
-				}
-
-				if tv.activeRow > maxActiveRow {
-					tv.activeRow = maxActiveRow
-					if tv.topRow.Next() != nil {
-						tv.topRow = tv.topRow.Next()
-					}
-				}
-
+				tv.moveDown()
 				tv.updateTable(tbl)
 				ui.Render(tbl)
-				for tv.lines.Len() > maxLines {
-					tv.lines.Remove(tv.lines.Front())
-				}
+				tv.trimLines()
 			case "k", "<Up>":
 				// up a line
-				tv.activeRow--
-				if tv.activeRow < 1 {
-					tv.activeRow = 1
-
-					if tv.topRow.Prev() != nil {
-						tv.topRow = tv.topRow.Prev()
-					}
-				}
+				tv.moveUp()
 				tv.updateTable(tbl)
 				ui.Render(tbl)
+			case "y":
+				state = "copy"
+				copyPrompt()
+			case "d":
+				// show the active row in a popup
+				state = "detail"
+				p4.SelectedRow = 0
+				tv.updateDetail(p4, "")
+				ui.Render(p4)
+			case "v":
+				// show the active row vertically
+				state = "vertical"
+				tv.updateVertical(p2)
+				ui.Render(p2)
 			case "l", "<Right>":
 				// right a col
 				tv.leftCol++
-				if tv.leftCol >= len(tv.colWidth)-support.BoolSum(tv.colSticky) {
-					tv.leftCol = len(tv.colWidth) - support.BoolSum(tv.colSticky) - 1
+				if tv.leftCol >= tv.scrollCols() {
+					tv.leftCol = tv.scrollCols() - 1
 				}
 				tv.updateTable(tbl)
 				ui.Render(tbl)
@@ -705,72 +1183,135 @@ ESC to hide help text
 			case "x":
 				state = "select"
 				tv.colSelectMode = true
-				if support.BoolSum(tv.colSticky) > 0 {
+				if tv.stickyCount() > 0 {
 					tv.leftCol = 0
 				}
 
 				tv.updateTable(tbl)
 				ui.Render(tbl)
 			case "s":
-				count := 0
-				for e := tv.topRow; e != nil; e = e.Next() {
-					t, _ := e.Value.(*TextRecord)
-					if t.Flag {
-						count++
+				// save the marked rows (or all of the rows, if none are marked)
+				state = "save"
+				saveOpts.marked = tv.markCount() > 0
+				savePrompt()
+			case "n", "N":
+				// find the next match (N to search in the other direction)
+				if tv.search == nil {
+					break
+				}
+				p0.Text = " Searching... (Ctrl-C to cancel)"
+				ui.Render(p0)
+
+				back := tv.searchBack
+				if e.ID == "N" {
+					back = !back
+				}
+				found, cancelled := tv.findMatch(back, checkCancel)
+				tv.trimLines()
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+
+				if cancelled {
+					p0.Text = " Search cancelled"
+				} else if !found {
+					p0.Text = " Not found!"
+				} else {
+					showMatchStatus()
+					break
+				}
+				ui.Render(p0)
+			case "i", "w":
+				// change how searches match (and update the current search)
+				if e.ID == "i" {
+					tv.caseMode = (tv.caseMode + 1) % len(caseModeNames)
+				} else {
+					tv.wholeCell = !tv.wholeCell
+				}
+				if tv.search != nil {
+					search, err := newPagerSearch(tv.search.query, tv.txt.Header, tv.activeColumn(), tv.caseMode, tv.wholeCell)
+					if err == nil {
+						tv.search = search
 					}
 				}
-				if count == 0 {
-					p0.Text = " No rows selected "
-					state = "save_error"
-					tb.HideCursor()
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+				p0.Text = " Search mode: " + tv.searchModes()
+				ui.Render(p0)
+			case "o":
+				// sort by the active column (or change how it is sorted)
+				p0.Text = " Sorting..."
+				ui.Render(p0)
+				err := tv.nextSort(tv.activeColumn(), events, func(msg string) {
+					p0.Text = msg
+					ui.Render(p0)
+				})
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+				if err == errCancelled {
+					p0.Text = " Sort cancelled"
+					ui.Render(p0)
+				} else if err != nil {
+					p0.Text = " Unable to sort: " + err.Error()
 					ui.Render(p0)
 				} else {
-					state = "save"
-					p0.Text = " Save marked rows to file: " + savePath
-					tb.SetCursor(len(p0.Text)+1, 1)
+					p0.Text = " Sort: " + tv.sortStatus()
 					ui.Render(p0)
 				}
-			case "n":
-				// find next match
-				if tv.searchQuery == "" {
-					break
-				}
-				found := false
-				for el := tv.topRow; !found && el != nil; el = el.Next() {
-					line, ok := el.Value.(*TextRecord)
-					if !ok || line == nil || line.Values == nil {
-						continue
-					}
-					for i, v := range line.Values {
-						if el == tv.topRow && i <= lastMatchCol {
-							continue
-						}
-						if strings.Contains(v, tv.searchQuery) {
-							found = true
-							tv.leftCol = i
-							tv.topRow = el
-							tv.activeRow = 1
-							lastMatchCol = i
-							break
-						}
-					}
-					if !found && el.Next() == nil && !tv.txt.isEOF {
-						l, err := tv.txt.ReadLine()
-						if err != nil {
-							break
-						}
-						tv.lines.PushBack(l)
+			case "-":
+				// hide the active column
+				tv.toggleColumn(tv.activeColumn())
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+			case "+":
+				tv.showAllColumns()
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+			case "<", ">":
+				// move the active column (it stays the active column)
+				if tv.moveColumn(tv.activeColumn(), e.ID == ">") {
+					if e.ID == ">" {
+						tv.leftCol++
+					} else {
+						tv.leftCol--
 					}
 				}
-				if found {
-					for tv.lines.Len() > maxLines {
-						tv.lines.Remove(tv.lines.Front())
-					}
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+			case "[", "]":
+				if e.ID == "]" {
+					tv.resizeColumn(tv.activeColumn(), 1)
+				} else {
+					tv.resizeColumn(tv.activeColumn(), -1)
 				}
 				tv.updateTable(tbl)
 				ui.Render(tbl)
-			case "/":
-				p0.Text = " Search: " + query
+			case "=":
+				tv.fitColumn(tv.activeColumn())
+				tv.updateTable(tbl)
+				ui.Render(tbl)
+			case "C":
+				state = "columns"
+				tv.updateColumnList(p3)
+				ui.Render(p3)
+			case "&", "f":
+				state = "filter"
+				p0.Text = " Filter: " + filterQuery
+				tb.SetCursor(len(p0.Text)+1, 1)
+				ui.Render(p0)
+			case "F":
+				// remove the last filter
+				if len(tv.filters) == 0 {
+					break
+				}
+				tv.filters = tv.filters[:len(tv.filters)-1]
+				if len(tv.filters) == 0 {
+					clearFilters()
+				} else {
+					applyFilters(false)
+				}
+			case "/", "?":
+				tv.searchBack = e.ID == "?"
+				p0.Text = tv.searchPrompt(query)
 				tb.SetCursor(len(p0.Text)+1, 1)
 
 				ui.Render(p0)
@@ -786,19 +1327,31 @@ var activeStyle ui.Style = ui.NewStyle(ui.ColorClear, ui.ColorClear, ui.Modifier
 var headerStyle ui.Style = ui.NewStyle(ui.ColorClear, ui.ColorClear, ui.ModifierBold|ui.ModifierUnderline)
 var markedStyle ui.Style = ui.NewStyle(ui.ColorGreen, ui.ColorClear, ui.ModifierBold|ui.ModifierReverse)
 
-func (tv *TextPager) updateTable(tbl *widgets.Table) {
+func (tv *TextPager) updateTable(tbl *pagerTable) {
+	// line numbers are always shown for filtered rows (so we can refer back to the original rows)
+	lnWidth := 0
+	if tv.showLineNum || len(tv.filters) > 0 {
+		lnWidth = tv.lineNumWidth() + 1
+	}
+
 	var showCols []int
-	if support.BoolSum(tv.colSticky) > 0 {
-		showCols = make([]int, len(tv.colNames)+1)
+	if tv.stickyCount() > 0 {
+		showCols = make([]int, len(tv.colNames)+2)
 	} else {
-		showCols = make([]int, len(tv.colNames))
+		showCols = make([]int, len(tv.colNames)+1)
 	}
 	showColCount := 0
 
 	size := 1
-	j := -support.BoolSum(tv.colSticky)
+	if lnWidth > 0 {
+		showCols[showColCount] = -2
+		showColCount++
+		size += lnWidth
+	}
+	j := -tv.stickyCount()
 
-	for i, v := range tv.colWidth {
+	for _, i := range tv.columns() {
+		v := tv.colWidth[i]
 		if tv.colSticky[i] {
 			size += v + 1
 			showCols[showColCount] = i
@@ -810,13 +1363,14 @@ func (tv *TextPager) updateTable(tbl *widgets.Table) {
 		}
 	}
 
-	if support.BoolSum(tv.colSticky) > 0 {
+	if tv.stickyCount() > 0 {
 		size++
 		showCols[showColCount] = -1
 		showColCount++
 	}
 
-	for i, v := range tv.colWidth {
+	for _, i := range tv.columns() {
+		v := tv.colWidth[i]
 		if !tv.colSticky[i] {
 			if j >= tv.leftCol {
 				size += v + 1
@@ -842,62 +1396,55 @@ func (tv *TextPager) updateTable(tbl *widgets.Table) {
 	widths := make([]int, showColCount)
 
 	size = 1
-	j = -support.BoolSum(tv.colSticky)
+	j = -tv.stickyCount()
 	k := 0
-	for i, v := range tv.colNames {
+	if lnWidth > 0 {
+		headerVals[k] = support.PadRight("#", lnWidth)
+		widths[k] = support.MinInt(lnWidth, tv.visibleCols-size)
+		size += lnWidth
+		k++
+	}
+	for _, i := range tv.columns() {
+		v := tv.colNames[i]
 		if tv.colSticky[i] {
-			for len(v) < tv.colWidth[i] {
-				v += " "
-			}
-			headerVals[k] = v + "*"
+			headerVals[k] = headerCell(tv.headerName(i, v), tv.colWidth[i], "*", j == tv.leftCol && tv.colSelectMode)
 			widths[k] = support.MaxInt(0, support.MinInt(tv.colWidth[i]+1, tv.visibleCols-size))
 			size += tv.colWidth[i] + 1
 
-			r := []rune(v)
-			if len(r) > tv.colWidth[i] {
-				headerVals[k] = string(r[:tv.colWidth[i]]) + "$"
-			}
-
-			if j == tv.leftCol && tv.colSelectMode {
-				headerVals[k] = headerVals[k][0:len(headerVals[k])-3] + "<=*"
-				// headerVals[k] = headerVals[k] + "<="
-			}
-
 			k++
 			j++
 		}
 	}
-	if support.BoolSum(tv.colSticky) > 0 {
+	if tv.stickyCount() > 0 {
 		headerVals[k] = ""
 		widths[k] = 0
 		size++
 		k++
 	}
-	for i, v := range tv.colNames {
+	for _, i := range tv.columns() {
+		v := tv.colNames[i]
 		if !tv.colSticky[i] && k < showColCount {
 			if j >= tv.leftCol {
-				for len(v) < tv.colWidth[i] {
-					v += " "
-				}
-				headerVals[k] = v + " "
+				headerVals[k] = headerCell(tv.headerName(i, v), tv.colWidth[i], " ", j == tv.leftCol && tv.colSelectMode)
 				widths[k] = support.MaxInt(0, support.MinInt(tv.colWidth[i]+1, tv.visibleCols-size))
 				size += tv.colWidth[i] + 1
-				r := []rune(v)
-				if len(r) > tv.colWidth[i] {
-					headerVals[k] = string(r[:tv.colWidth[i]]) + "$"
-				}
-
-				if j == tv.leftCol && tv.colSelectMode {
-					headerVals[k] = headerVals[k][0:len(headerVals[k])-3] + "<= "
-				}
 				k++
 			}
 			j++
 		}
 	}
 
+	// columns that are fully visible
+	tv.shownCols = tv.shownCols[:0]
+	for k, v := range showCols[:showColCount] {
+		if v >= 0 && widths[k] > tv.colWidth[v] {
+			tv.shownCols = append(tv.shownCols, v)
+		}
+	}
+
 	tbl.ColumnWidths = widths
 	tbl.Rows[0] = headerVals
+	tbl.Status = tv.statusLine()
 	tbl.RowStyles[0] = headerStyle
 	if tv.colSelectMode {
 		tbl.RowStyles[0] = activeStyle
@@ -913,16 +1460,19 @@ func (tv *TextPager) updateTable(tbl *widgets.Table) {
 		line, _ := e.Value.(*TextRecord)
 
 		for j, v := range showCols[:showColCount] {
-			if v >= 0 && len(line.Values) > v {
+			if v == -2 {
+				if line.Values != nil {
+					vals[j] = support.PadLeft(strconv.Itoa(line.DataLineNum), lnWidth-1)
+				}
+			} else if v >= 0 && len(line.Values) > v {
 				vals[j] = line.Values[v]
 
-				r := []rune(line.Values[v])
-				if len(r) > widths[j] {
-					vals[j] = string(r[:widths[j]]) + "$"
+				if support.StringWidth(vals[j]) > widths[j] {
+					vals[j] = support.TruncateWidth(vals[j], widths[j]) + "$"
 				}
 
-				if tv.searchQuery != "" && strings.Contains(vals[j], tv.searchQuery) {
-					vals[j] = strings.ReplaceAll(vals[j], tv.searchQuery, "["+tv.searchQuery+"](fg:yellow,mod:bold)")
+				if tv.search != nil {
+					vals[j] = tv.search.highlight(v, line.Values[v], vals[j])
 				}
 			} else {
 				// pad out the end if we are missing values for this row
@@ -946,11 +1496,9 @@ func (tv *TextPager) updateTable(tbl *widgets.Table) {
 		if e.Next() == nil && !tv.txt.isEOF {
 			// need to load more lines!
 			// qfmt.Fprintln(os.Stderr, "Loading more lines")
-			l, err := tv.txt.ReadLine()
-			if err != nil {
+			if !tv.pushLine() {
 				break
 			}
-			tv.lines.PushBack(l)
 		}
 		e = e.Next()
 	}
@@ -961,38 +1509,148 @@ func (tv *TextPager) updateTable(tbl *widgets.Table) {
 
 }
 
-func (tv *TextPager) clearMarked() {
-	e := tv.topRow
-	for i := 0; e.Next() != nil; i++ {
-		t, _ := e.Value.(*TextRecord)
-		t.Flag = false
-		e = e.Next()
+// headerName - the name shown for a column (with an indicator for the sorted column)
+func (tv *TextPager) headerName(col int, name string) string {
+	if tv.sortMode != sortNone && col == tv.sortCol {
+		return name + sortIndicators[tv.sortMode]
+	}
+	return name
+}
+
+// lineNumWidth - the width of the line numbers (this only grows, so the columns don't move around)
+func (tv *TextPager) lineNumWidth() int {
+	n := tv.totalLines
+	if back := tv.lines.Back(); back != nil {
+		t, _ := back.Value.(*TextRecord)
+		n = support.MaxInt(n, t.DataLineNum)
+	}
+	tv.lnWidth = support.MaxInt(tv.lnWidth, len(strconv.Itoa(n)))
+	return tv.lnWidth
+}
+
+// activeColumn - the index of the active (left-most, non-sticky) column
+func (tv *TextPager) activeColumn() int {
+	j := 0
+	for _, i := range tv.columns() {
+		if !tv.colSticky[i] {
+			if j == tv.leftCol {
+				return i
+			}
+			j++
+		}
 	}
+	return 0
 }
 
-func (tv *TextPager) saveToFile(fname string) error {
+// searchModes - describe the current search modes
+func (tv *TextPager) searchModes() string {
+	if tv.wholeCell {
+		return caseModeNames[tv.caseMode] + ", whole cell"
+	}
+	return caseModeNames[tv.caseMode]
+}
 
-	f, err := os.Create(fname)
-	defer f.Close()
-	if err != nil {
-		return err
+// searchPrompt - the prompt for entering a search query
+func (tv *TextPager) searchPrompt(query string) string {
+	if tv.searchBack {
+		return " Search backward (" + tv.searchModes() + "): " + query
 	}
+	return " Search (" + tv.searchModes() + "): " + query
+}
 
-	if tv.txt.headerComment {
-		f.WriteString(tv.txt.lastComment)
-	} else if tv.txt.rawHeaderLine != "" {
-		f.WriteString(tv.txt.rawHeaderLine)
+// typedText - the text for a key typed at a prompt (false for other keys, like "<Enter>"). Keys
+// are named like "<Enter>", so '<' and '>' are the only keys that start (or end) with them.
+func typedText(id string) (string, bool) {
+	switch {
+	case id == "<Space>":
+		return " ", true
+	case id == "<" || id == ">":
+		return id, true
+	case id[0:1] != "<" && id[len(id)-1:] != ">":
+		return id, true
 	}
+	return "", false
+}
 
-	e := tv.topRow
-	for i := 0; e.Next() != nil; i++ {
-		t, _ := e.Value.(*TextRecord)
-		if t.Flag {
-			f.WriteString(t.RawString)
+// headerCell - format a column name to fill the column width (by display width). Names that are
+// too long are truncated with a '$' marker. The selected column (in column select mode) is marked with "<=".
+func headerCell(name string, width int, suffix string, selected bool) string {
+	var cell string
+	if support.StringWidth(name) > width {
+		cell = support.PadRight(support.TruncateWidth(name, width), width) + "$"
+	} else {
+		cell = support.PadRight(name, width) + suffix
+	}
+
+	if selected {
+		cell = support.PadRight(support.TruncateWidth(cell, width-2), width-2) + "<=" + suffix
+	}
+	return cell
+}
+
+// moveDown - move the active row down one row (scrolling if needed)
+func (tv *TextPager) moveDown() {
+	tv.activeRow++
+
+	maxActiveRow := 0
+
+	for last := tv.topRow; last.Next() != nil && maxActiveRow < tv.visibleRows-3; last = last.Next() {
+		maxActiveRow++
+	}
+
+	if tv.activeRow > maxActiveRow {
+		tv.activeRow = maxActiveRow
+		if tv.topRow.Next() != nil {
+			tv.topRow = tv.topRow.Next()
 		}
+	}
+}
+
+// moveUp - move the active row up one row (scrolling if needed)
+func (tv *TextPager) moveUp() {
+	tv.activeRow--
+	if tv.activeRow < 1 {
+		tv.activeRow = 1
+
+		if tv.topRow.Prev() != nil || tv.loadBefore() {
+			tv.topRow = tv.topRow.Prev()
+		}
+	}
+}
 
+// activeRecord - return the record for the active row
+func (tv *TextPager) activeRecord() *TextRecord {
+	e := tv.topRow
+	for i := 0; e.Next() != nil && i < tv.activeRow-1; i++ {
 		e = e.Next()
 	}
+	t, _ := e.Value.(*TextRecord)
+	return t
+}
+
+// updateVertical - show the active row as "name | value" lines
+func (tv *TextPager) updateVertical(p *pagerList) {
+	t := tv.activeRecord()
+	if t == nil {
+		return
+	}
+
+	nameWidth := 0
+	for _, name := range tv.txt.Header {
+		nameWidth = support.MaxInt(nameWidth, support.StringWidth(name))
+	}
 
-	return nil
+	// the list has a border, so there are two less columns
+	p.Rows = verticalRecord(tv.txt.Header, t.Values, support.MaxInt(1, tv.visibleCols-nameWidth-5), true)
+	p.Title = fmt.Sprintf(" Row %d (v to go back) ", t.DataLineNum)
+}
+
+// clearMarked - clear all of the marks (including lines that aren't in the buffer)
+func (tv *TextPager) clearMarked() {
+	tv.marks = make(map[int]bool)
+	tv.markedRecords = make(map[int]*TextRecord)
+	for e := tv.lines.Front(); e != nil; e = e.Next() {
+		t, _ := e.Value.(*TextRecord)
+		t.Flag = false
+	}
 }
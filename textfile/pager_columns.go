@@ -0,0 +1,114 @@
+package textfile
+
+import (
+	"github.com/mbreese/tabl/support"
+)
+
+// The columns in the pager can be hidden, moved, and resized. colOrder is the order that the
+// columns are shown in, and colHidden marks the columns that aren't shown. The column indexes
+// (for colNames, colWidth, etc) are always the same as the file.
+
+// columns - the columns that are shown, in order
+func (tv *TextPager) columns() []int {
+	cols := make([]int, 0, len(tv.colOrder))
+	for _, i := range tv.colOrder {
+		if !tv.colHidden[i] {
+			cols = append(cols, i)
+		}
+	}
+	return cols
+}
+
+// stickyCount - the number of sticky columns that are shown
+func (tv *TextPager) stickyCount() int {
+	count := 0
+	for _, i := range tv.columns() {
+		if tv.colSticky[i] {
+			count++
+		}
+	}
+	return count
+}
+
+// scrollCols - the number of (non-sticky) columns that are shown, which can be scrolled
+func (tv *TextPager) scrollCols() int {
+	return len(tv.columns()) - tv.stickyCount()
+}
+
+// toggleColumn - hide (or show) a column. The last column can't be hidden.
+func (tv *TextPager) toggleColumn(col int) bool {
+	if !tv.colHidden[col] && len(tv.columns()) <= 1 {
+		return false
+	}
+	tv.colHidden[col] = !tv.colHidden[col]
+	tv.leftCol = support.MaxInt(0, support.MinInt(tv.leftCol, tv.scrollCols()-1))
+	return true
+}
+
+// showAllColumns - show any hidden columns
+func (tv *TextPager) showAllColumns() {
+	for i := range tv.colHidden {
+		tv.colHidden[i] = false
+	}
+}
+
+// moveColumn - move a column left (or right), past the next column that is shown. This returns
+// false if the column is already at the start (or end).
+func (tv *TextPager) moveColumn(col int, right bool) bool {
+	pos := -1
+	for k, i := range tv.colOrder {
+		if i == col {
+			pos = k
+		}
+	}
+
+	// the next column that is shown (in the same section, sticky or not)
+	other := -1
+	for k := pos; other < 0; {
+		if right {
+			k++
+		} else {
+			k--
+		}
+		if k < 0 || k >= len(tv.colOrder) {
+			return false
+		}
+		if i := tv.colOrder[k]; !tv.colHidden[i] && tv.colSticky[i] == tv.colSticky[col] {
+			other = k
+		}
+	}
+
+	tv.colOrder[pos], tv.colOrder[other] = tv.colOrder[other], tv.colOrder[pos]
+	return true
+}
+
+// resizeColumn - make a column wider (or narrower)
+func (tv *TextPager) resizeColumn(col int, delta int) {
+	tv.colWidth[col] = support.MaxInt(2, tv.colWidth[col]+delta)
+}
+
+// fitColumn - set the width of a column to fit the name and the values in memory
+func (tv *TextPager) fitColumn(col int) {
+	width := support.MaxInt(tv.minWidth, support.StringWidth(tv.colNames[col]+"   "))
+	for el := tv.lines.Front(); el != nil; el = el.Next() {
+		if t, _ := el.Value.(*TextRecord); col < len(t.Values) {
+			width = support.MaxInt(width, support.StringWidth(t.Values[col]+" "))
+		}
+	}
+	tv.colWidth[col] = width
+}
+
+// updateColumnList - list all of the columns (in order), with a checkbox for the ones that are shown
+func (tv *TextPager) updateColumnList(l *pagerList) {
+	l.Rows = make([]string, len(tv.colOrder))
+	for k, i := range tv.colOrder {
+		box := "[x] "
+		if tv.colHidden[i] {
+			box = "[ ] "
+		}
+		l.Rows[k] = box + tv.colNames[i]
+		if tv.colSticky[i] {
+			l.Rows[k] += " *"
+		}
+	}
+}
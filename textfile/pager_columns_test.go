@@ -0,0 +1,144 @@
+package textfile
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"testing"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+func TestPagerMoveColumn(t *testing.T) {
+	tv := newTestPager(t, "testdata/test.txt")
+
+	tests := []struct {
+		col      int
+		right    bool
+		moved    bool
+		expected string
+	}{
+		{0, false, false, "[0 1 2]"},
+		{2, true, false, "[0 1 2]"},
+		{0, true, true, "[1 0 2]"},
+		{0, true, true, "[1 2 0]"},
+		{0, true, false, "[1 2 0]"},
+		{1, false, false, "[1 2 0]"},
+		{0, false, true, "[1 0 2]"},
+	}
+
+	for _, test := range tests {
+		if moved := tv.moveColumn(test.col, test.right); moved != test.moved {
+			t.Errorf("moveColumn(%d, %v) = %v, expected %v", test.col, test.right, moved, test.moved)
+		}
+		if got := fmt.Sprint(tv.columns()); got != test.expected {
+			t.Errorf("moveColumn(%d, %v): got %s, expected %s", test.col, test.right, got, test.expected)
+		}
+	}
+}
+
+func TestPagerMoveColumnSkips(t *testing.T) {
+	tv := newTestPager(t, "testdata/test.txt")
+
+	// hidden columns are skipped
+	tv.toggleColumn(1)
+	if !tv.moveColumn(0, true) {
+		t.Fatalf("expected the column to move")
+	}
+	if got := fmt.Sprint(tv.colOrder); got != "[2 1 0]" {
+		t.Errorf("expected [2 1 0], got %s", got)
+	}
+	tv.showAllColumns()
+
+	// sticky columns only move past other sticky columns
+	tv.colOrder = []int{0, 1, 2}
+	tv.colSticky[0] = true
+	if tv.moveColumn(0, true) {
+		t.Errorf("expected the sticky column not to move")
+	}
+	if tv.moveColumn(1, false) {
+		t.Errorf("expected the column not to move past the sticky column")
+	}
+	if !tv.moveColumn(1, true) {
+		t.Errorf("expected the column to move")
+	}
+	if got := fmt.Sprint(tv.columns()); got != "[0 2 1]" {
+		t.Errorf("expected [0 2 1], got %s", got)
+	}
+}
+
+func TestPagerToggleColumn(t *testing.T) {
+	tv := newTestPager(t, "testdata/test.txt")
+
+	tests := []struct {
+		col      int
+		toggled  bool
+		expected string
+	}{
+		{1, true, "[0 2]"},
+		{0, true, "[2]"},
+		// the last column that is shown can't be hidden
+		{2, false, "[2]"},
+		{0, true, "[0 2]"},
+		{2, true, "[0]"},
+		{0, false, "[0]"},
+		{1, true, "[0 1]"},
+	}
+
+	for _, test := range tests {
+		if toggled := tv.toggleColumn(test.col); toggled != test.toggled {
+			t.Errorf("toggleColumn(%d) = %v, expected %v", test.col, toggled, test.toggled)
+		}
+		if got := fmt.Sprint(tv.columns()); got != test.expected {
+			t.Errorf("toggleColumn(%d): got %s, expected %s", test.col, got, test.expected)
+		}
+	}
+
+	tv.showAllColumns()
+	if got := fmt.Sprint(tv.columns()); got != "[0 1 2]" {
+		t.Errorf("showAllColumns: got %s, expected [0 1 2]", got)
+	}
+}
+
+func TestPagerToggleColumnLeftCol(t *testing.T) {
+	tv := newTestPager(t, "testdata/test.txt")
+
+	// scrolled to the last column, which is then hidden
+	tv.leftCol = 2
+	tv.toggleColumn(2)
+	if tv.leftCol != 1 {
+		t.Errorf("expected leftCol 1, got %d", tv.leftCol)
+	}
+}
+
+// drawnLines - draw a widget into a buffer, and return the text for each line inside the border
+func drawnLines(d ui.Drawable, inner image.Rectangle) []string {
+	buf := ui.NewBuffer(d.GetRect())
+	d.Draw(buf)
+
+	var lines []string
+	for y := inner.Min.Y; y < inner.Max.Y; y++ {
+		var sb strings.Builder
+		for x := inner.Min.X; x < inner.Max.X; x++ {
+			sb.WriteRune(buf.GetCell(image.Pt(x, y)).Rune)
+		}
+		lines = append(lines, strings.TrimRight(sb.String(), " "))
+	}
+	return lines
+}
+
+func TestPagerColumnList(t *testing.T) {
+	tv := newTestPager(t, "testdata/test.txt")
+	tv.colNames[1] = "[col2](fg:red)"
+	tv.colSticky[2] = true
+	tv.toggleColumn(0)
+
+	l := newPagerList()
+	l.SetRect(0, 0, 30, 5)
+	tv.updateColumnList(l)
+
+	expected := []string{"[ ] col1", "[x] [col2](fg:red)", "[x] col3 *"}
+	if got := drawnLines(l, l.Inner); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,100 @@
+package textfile
+
+import (
+	"fmt"
+
+	"github.com/mbreese/tabl/support"
+)
+
+// rowDetail is a popup with the active row, as "name: value" lines. Long values are wrapped, so
+// each field can have more than one line. (The values are shown as-is, see pagerList.)
+type rowDetail struct {
+	*pagerList
+	fields []int // the field for each line
+}
+
+// newRowDetail - create the row detail popup
+func newRowDetail() *rowDetail {
+	l := newPagerList()
+	l.SelectedRowStyle = activeStyle
+	return &rowDetail{pagerList: l}
+}
+
+// field - the selected field
+func (d *rowDetail) field() int {
+	if d.SelectedRow < len(d.fields) {
+		return d.fields[d.SelectedRow]
+	}
+	return 0
+}
+
+// selectField - select the first line of a field
+func (d *rowDetail) selectField(field int) {
+	d.SelectedRow = 0
+	for i, f := range d.fields {
+		if f == field {
+			d.SelectedRow = i
+			return
+		}
+	}
+}
+
+// updateDetail - show the active row in the detail popup (keeping the same field selected). The
+// status is shown in the title.
+func (tv *TextPager) updateDetail(d *rowDetail, status string) {
+	t := tv.activeRecord()
+	if t == nil {
+		return
+	}
+	field := d.field()
+
+	d.SetRect(2, 1, support.MaxInt(3, tv.visibleCols-2), support.MaxInt(3, tv.visibleRows-1))
+
+	nameWidth := 0
+	for _, name := range tv.txt.Header {
+		nameWidth = support.MaxInt(nameWidth, support.StringWidth(name))
+	}
+	// (there is a border, and the ": " after the name)
+	width := support.MaxInt(1, d.Inner.Dx()-nameWidth-2)
+
+	d.Rows = d.Rows[:0]
+	d.fields = d.fields[:0]
+	for i, name := range tv.txt.Header {
+		v := ""
+		if i < len(t.Values) {
+			v = t.Values[i]
+		}
+		for j, part := range support.WrapWidth(v, width, true) {
+			if j == 0 {
+				d.Rows = append(d.Rows, support.PadRight(name+":", nameWidth+1)+" "+part)
+			} else {
+				d.Rows = append(d.Rows, support.PadRight("", nameWidth+1)+" "+part)
+			}
+			d.fields = append(d.fields, i)
+		}
+	}
+	d.selectField(field)
+
+	if status == "" {
+		status = "n/p next/previous row, y to copy, q to close"
+	}
+	d.Title = fmt.Sprintf(" Row %d (%s) ", t.DataLineNum, status)
+}
+
+// copyField - copy the selected value to the clipboard
+func (tv *TextPager) copyField(d *rowDetail) string {
+	t := tv.activeRecord()
+	field := d.field()
+	if t == nil || field >= len(tv.txt.Header) {
+		return ""
+	}
+
+	v := ""
+	if field < len(t.Values) {
+		v = t.Values[field]
+	}
+	if err := tv.copyText(v); err != nil {
+		return "Unable to copy: " + err.Error()
+	}
+	return "copied " + tv.txt.Header[field]
+}
@@ -0,0 +1,119 @@
+package textfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPagerDetail(t *testing.T) {
+	tv := newTestPager(t, "testdata/view_wrap.txt")
+	tv.visibleCols = 30
+
+	d := newRowDetail()
+	tv.updateDetail(d, "")
+
+	// the values are wrapped to fit after the names (the names are padded to line up)
+	expected := []string{
+		"id:   1",
+		"desc: The quick brown",
+		"      fox jumps over the",
+		"      lazy dog",
+		"n:    5",
+	}
+	if strings.Join(d.Rows, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %q, got %q", expected, d.Rows)
+	}
+	if got := strings.Join(drawnLines(d, d.Inner)[:len(expected)], "\n"); got != strings.Join(expected, "\n") {
+		t.Errorf("expected %q to be drawn, got %q", expected, got)
+	}
+	if d.Title != " Row 1 (n/p next/previous row, y to copy, q to close) " {
+		t.Errorf("unexpected title: %q", d.Title)
+	}
+
+	fields := []int{0, 1, 1, 1, 2}
+	for i, field := range fields {
+		d.SelectedRow = i
+		if d.field() != field {
+			t.Errorf("line %d: expected field %d, got %d", i, field, d.field())
+		}
+	}
+}
+
+func TestPagerDetailBrackets(t *testing.T) {
+	tv := newTestPager(t, "testdata/view_wrap.txt")
+	tv.activeRecord().Values[1] = "[x](fg:red) [y]"
+
+	d := newRowDetail()
+	tv.updateDetail(d, "")
+
+	if got := drawnLines(d, d.Inner)[1]; got != "desc: [x](fg:red) [y]" {
+		t.Errorf("expected the value as-is, got %q", got)
+	}
+}
+
+func TestPagerDetailNextRow(t *testing.T) {
+	tv := newTestPager(t, "testdata/view_wrap.txt")
+	tv.visibleCols = 30
+
+	d := newRowDetail()
+	tv.updateDetail(d, "")
+
+	// the same field stays selected on the next row (which has fewer lines)
+	d.SelectedRow = 4
+	tv.moveDown()
+	tv.updateDetail(d, "")
+	if d.Rows[d.SelectedRow] != "n:    6" || d.field() != 2 {
+		t.Errorf("expected the n field to be selected, got %q", d.Rows[d.SelectedRow])
+	}
+	if !strings.HasPrefix(d.Title, " Row 2 ") {
+		t.Errorf("unexpected title: %q", d.Title)
+	}
+
+	// and the first line of a wrapped field
+	d.SelectedRow = 1
+	tv.moveDown()
+	tv.updateDetail(d, "copied desc")
+	if d.Rows[d.SelectedRow] != "desc: Supercalifragilist" || d.field() != 1 {
+		t.Errorf("expected the desc field to be selected, got %q", d.Rows[d.SelectedRow])
+	}
+	if d.Title != " Row 3 (copied desc) " {
+		t.Errorf("unexpected title: %q", d.Title)
+	}
+
+	tv.moveUp()
+	tv.moveUp()
+	tv.updateDetail(d, "")
+	if d.SelectedRow != 1 || !strings.HasPrefix(d.Title, " Row 1 ") {
+		t.Errorf("expected the first line for desc in row 1, got line %d (%q)", d.SelectedRow, d.Title)
+	}
+}
+
+func TestPagerDetailScroll(t *testing.T) {
+	tv := newTestPager(t, "testdata/view_wrap.txt")
+	tv.visibleCols = 20
+	tv.visibleRows = 7
+
+	d := newRowDetail()
+	tv.updateDetail(d, "")
+	if d.Inner.Dy() != 3 || len(d.Rows) <= 3 {
+		t.Fatalf("expected more lines (%d) than fit (%d)", len(d.Rows), d.Inner.Dy())
+	}
+
+	// the selected line is kept in view
+	d.ScrollBottom()
+	lines := drawnLines(d, d.Inner)
+	if strings.TrimSuffix(lines[2], "▼") != d.Rows[len(d.Rows)-1] || !strings.HasSuffix(lines[0], "▲") {
+		t.Errorf("expected the last line at the bottom, got %q", lines)
+	}
+
+	// page up selects the top line that is shown first
+	top := len(d.Rows) - 3
+	d.ScrollPageUp()
+	if d.SelectedRow != top {
+		t.Errorf("expected line %d to be selected, got %d", top, d.SelectedRow)
+	}
+	d.ScrollPageUp()
+	if d.SelectedRow != top-3 {
+		t.Errorf("expected line %d to be selected, got %d", top-3, d.SelectedRow)
+	}
+}
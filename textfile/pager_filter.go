@@ -0,0 +1,201 @@
+package textfile
+
+import (
+	"strconv"
+	"strings"
+)
+
+// pagerFilter is a filter for the rows shown in the pager. A filter is either a comparison
+// with a named column ("name=value", "name!=value", "name<value", "name<=value", "name>value",
+// or "name>=value"), or a search query (see pagerSearch) that matches a column or any column.
+// Filters that start with '!' show the rows that don't match.
+type pagerFilter struct {
+	query  string
+	invert bool
+	search *pagerSearch
+	col    int
+	op     string
+	value  string
+}
+
+// filterOps are the comparison operators (the two character operators are first, so that
+// "a<=1" isn't read as "a<" and "=1")
+var filterOps = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// newPagerFilter - parse a filter query
+func newPagerFilter(query string, header []string, activeCol int, caseMode int, wholeCell bool) (*pagerFilter, error) {
+	f := &pagerFilter{
+		query: query,
+		col:   -1,
+	}
+
+	q := query
+	if strings.HasPrefix(q, "!") {
+		f.invert = true
+		q = q[1:]
+	}
+
+	// is this a comparison with a column?
+	for i, name := range header {
+		if name == "" || !strings.HasPrefix(q, name) {
+			continue
+		}
+		for _, op := range filterOps {
+			if strings.HasPrefix(q[len(name):], op) {
+				f.col = i
+				f.op = op
+				f.value = q[len(name)+len(op):]
+				return f, nil
+			}
+		}
+	}
+
+	search, err := newPagerSearch(q, header, activeCol, caseMode, wholeCell)
+	if err != nil {
+		return nil, err
+	}
+	f.search = search
+	return f, nil
+}
+
+// keep - should this row be shown?
+func (f *pagerFilter) keep(t *TextRecord) bool {
+	if f.search != nil {
+		for i, v := range t.Values {
+			if f.search.match(i, v) {
+				return !f.invert
+			}
+		}
+		return f.invert
+	}
+
+	v := ""
+	if f.col < len(t.Values) {
+		v = t.Values[f.col]
+	}
+	return f.compare(v) != f.invert
+}
+
+// compare - compare a value to the filter value (as numbers, if they are both numbers)
+func (f *pagerFilter) compare(v string) bool {
+	cmp := strings.Compare(v, f.value)
+	if a, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+		if b, err := strconv.ParseFloat(strings.TrimSpace(f.value), 64); err == nil {
+			switch {
+			case a < b:
+				cmp = -1
+			case a > b:
+				cmp = 1
+			default:
+				cmp = 0
+			}
+		}
+	}
+
+	switch f.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// keep - is a row shown (with all of the current filters)?
+func (tv *TextPager) keep(t *TextRecord) bool {
+	if len(tv.filters) == 0 {
+		return true
+	}
+	if t.Values == nil {
+		return false
+	}
+	for _, f := range tv.filters {
+		if !f.keep(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterStatus - the current filters
+func (tv *TextPager) filterStatus() string {
+	queries := make([]string, len(tv.filters))
+	for i, f := range tv.filters {
+		queries[i] = f.query
+	}
+	return strings.Join(queries, " & ")
+}
+
+// refilter - update the buffer after the filters have changed. The active row stays the same
+// if it is still shown, otherwise this is the next row that is shown (or the previous one).
+//
+// For stdin, rows that have been hidden can't be read again. So, the rows in memory are filtered,
+// and if none of them are shown, we read ahead until there is a row that is shown.
+func (tv *TextPager) refilter(cancel func() bool) (found bool, cancelled bool) {
+	cur := tv.activeRecord()
+	tv.counts = nil
+
+	// (scanFile only looks at the rows that are shown, so any of them will do)
+	kept := func(t *TextRecord) int {
+		return 0
+	}
+
+	if tv.canReread {
+		t, _, cancelled := tv.scanFile(cur.start, -1, false, kept, cancel)
+		if t == nil && !cancelled {
+			t, _, cancelled = tv.scanBefore(cur.fileLine(), kept, cancel)
+		}
+		if cancelled || t == nil {
+			return false, cancelled
+		}
+		el := tv.jumpTo(t)
+		if el == nil {
+			return false, false
+		}
+		tv.showElement(el)
+		return true, false
+	}
+
+	activeEl := tv.activeElement()
+	target := activeEl
+	for target != nil {
+		if t, _ := target.Value.(*TextRecord); tv.keep(t) {
+			break
+		}
+		target = target.Next()
+	}
+	if target == nil {
+		for target = activeEl.Prev(); target != nil; target = target.Prev() {
+			if t, _ := target.Value.(*TextRecord); tv.keep(t) {
+				break
+			}
+		}
+	}
+
+	if target == nil {
+		// nothing in memory is shown, so read ahead to the next row that is (the rows in memory
+		// are kept until we find one, in case there aren't any)
+		if !tv.pushLine() {
+			return false, false
+		}
+		target = tv.lines.Back()
+	}
+
+	for el := tv.lines.Front(); el != nil; {
+		next := el.Next()
+		if t, _ := el.Value.(*TextRecord); !tv.keep(t) {
+			tv.removeLine(el)
+		}
+		el = next
+	}
+	tv.centerOn(target)
+	return true, false
+}
@@ -0,0 +1,134 @@
+package textfile
+
+import (
+	"testing"
+)
+
+func TestPagerFilterParse(t *testing.T) {
+	header := []string{"a", "ab", "name"}
+
+	tests := []struct {
+		query  string
+		invert bool
+		search bool
+		col    int
+		op     string
+		value  string
+	}{
+		{"a=1", false, false, 0, "=", "1"},
+		{"a!=1", false, false, 0, "!=", "1"},
+		{"a<=1", false, false, 0, "<=", "1"},
+		{"a>=1", false, false, 0, ">=", "1"},
+		{"a<1", false, false, 0, "<", "1"},
+		{"a>1", false, false, 0, ">", "1"},
+		{"a=", false, false, 0, "=", ""},
+		{"a==1", false, false, 0, "=", "=1"},
+		{"!a=1", true, false, 0, "=", "1"},
+
+		// "ab=1" is a comparison with "ab", not "a" ("a" doesn't have an operator after it)
+		{"ab=1", false, false, 1, "=", "1"},
+		{"ab<=x y", false, false, 1, "<=", "x y"},
+		{"name>=bob", false, false, 2, ">=", "bob"},
+
+		// not a column name, so these are searches
+		{"abc=1", false, true, -1, "", ""},
+		{"x=1", false, true, -1, "", ""},
+		{"name:bob", false, true, -1, "", ""},
+		{"!bob", true, true, -1, "", ""},
+	}
+
+	for _, test := range tests {
+		f, err := newPagerFilter(test.query, header, 0, caseSmart, false)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.query, err)
+			continue
+		}
+		if f.invert != test.invert || (f.search != nil) != test.search || f.col != test.col || f.op != test.op || f.value != test.value {
+			t.Errorf("%q: got invert=%v search=%v col=%d op=%q value=%q, expected invert=%v search=%v col=%d op=%q value=%q",
+				test.query, f.invert, f.search != nil, f.col, f.op, f.value,
+				test.invert, test.search, test.col, test.op, test.value)
+		}
+	}
+
+	if _, err := newPagerFilter("!", header, 0, caseSmart, false); err == nil {
+		t.Errorf("\"!\": expected an error")
+	}
+}
+
+func TestPagerFilterCompare(t *testing.T) {
+	tests := []struct {
+		op       string
+		filter   string
+		value    string
+		expected bool
+	}{
+		// numbers
+		{"=", "10", "10", true},
+		{"=", "10", "10.0", true},
+		{"=", "10", " 10 ", true},
+		{"=", "1e1", "10", true},
+		{"!=", "10", "10.0", false},
+		{"!=", "10", "9", true},
+		{"<", "10", "9", true},
+		{"<", "10", "10", false},
+		{"<=", "10", "10", true},
+		{">", "10", "9", false},
+		{">", "10", "100", true},
+		{">=", "10", "10", true},
+		{">=", "-1", "-2", false},
+
+		// strings (or a string and a number)
+		{"=", "abc", "abc", true},
+		{"=", "abc", "ABC", false},
+		{"!=", "abc", "abd", true},
+		{"<", "10", "9x", false},
+		{"<", "abc", "abd", false},
+		{">", "abc", "abd", true},
+		{"<=", "abc", "abc", true},
+		{">=", "b", "a", false},
+		{"=", "", "", true},
+		{">", "", "a", true},
+	}
+
+	for _, test := range tests {
+		f := &pagerFilter{op: test.op, value: test.filter}
+		if got := f.compare(test.value); got != test.expected {
+			t.Errorf("%q %s %q: got %v, expected %v", test.value, test.op, test.filter, got, test.expected)
+		}
+	}
+}
+
+func TestPagerFilterKeep(t *testing.T) {
+	header := []string{"name", "count"}
+	row := &TextRecord{Values: []string{"Bob", "12"}}
+	short := &TextRecord{Values: []string{"Ann"}}
+
+	tests := []struct {
+		query string
+		row   *TextRecord
+		keep  bool
+	}{
+		{"count>5", row, true},
+		{"count<5", row, false},
+		{"!count<5", row, true},
+		{"count=", short, true},
+		{"count>1", short, false},
+		{"bob", row, true},
+		{"!bob", row, false},
+		{"Bob", row, true},
+		{"count:bob", row, false},
+		{"name:b", row, true},
+		{"xyz", row, false},
+		{"!xyz", row, true},
+	}
+
+	for _, test := range tests {
+		f, err := newPagerFilter(test.query, header, 0, caseSmart, false)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.query, err)
+		}
+		if got := f.keep(test.row); got != test.keep {
+			t.Errorf("%q: keep(%v) = %v, expected %v", test.query, test.row.Values, got, test.keep)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package textfile
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/mbreese/tabl/support"
+)
+
+// gotoLine - make a data line the active row. If the line isn't in the buffer, it is read again
+// (or we read ahead to it). Past the end of the file, this is the last line. If the rows are sorted,
+// this is the line number in the original file.
+func (tv *TextPager) gotoLine(n int, cancel func() bool) (found bool, cancelled bool) {
+	n = support.MaxInt(n, 1)
+
+	if tv.sortMode != sortNone {
+		// the rows aren't in order, so look for the line itself
+		for el := tv.lines.Front(); el != nil; el = el.Next() {
+			if t, _ := el.Value.(*TextRecord); t.Values != nil && t.DataLineNum == n {
+				tv.showElement(el)
+				return true, false
+			}
+		}
+		if tv.sortFile == "" {
+			return false, false
+		}
+		return tv.findLine(n, cancel)
+	}
+	return tv.gotoRow(n, cancel)
+}
+
+// findLine - find a data line in a sorted file (where the rows aren't in order). The file is read
+// from the start until the line is found.
+func (tv *TextPager) findLine(n int, cancel func() bool) (found bool, cancelled bool) {
+	rd, err := tv.txt.cloneAt(checkpoint{})
+	if err != nil {
+		return false, false
+	}
+	defer rd.Close()
+
+	for {
+		if cancel() {
+			return false, true
+		}
+		l, err := rd.ReadLine()
+		if err != nil {
+			if rd.isEOF {
+				tv.totalLines = rd.curDataLineNum
+			}
+			return false, false
+		}
+		if l.Values != nil && l.DataLineNum == n && tv.keep(l) {
+			el := tv.jumpTo(l)
+			if el == nil {
+				return false, false
+			}
+			tv.showElement(el)
+			return true, false
+		}
+	}
+}
+
+// gotoRow - make the nth data row in the file the active row (for a sorted file, this is the
+// position in the sorted rows)
+func (tv *TextPager) gotoRow(n int, cancel func() bool) (found bool, cancelled bool) {
+	var first, last *list.Element
+	for el := tv.lines.Front(); el != nil; el = el.Next() {
+		t, _ := el.Value.(*TextRecord)
+		if t.Values == nil {
+			continue
+		}
+		if first == nil {
+			first = el
+		}
+		last = el
+		if t.fileDataLine() >= n {
+			if el == first && t.fileDataLine() > n && tv.canReread {
+				// this is before the buffer
+				break
+			}
+			tv.showElement(el)
+			return true, false
+		}
+	}
+	if first == nil {
+		return false, false
+	}
+
+	if !tv.canReread {
+		// we can only read ahead
+		for {
+			if cancel() {
+				return false, true
+			}
+			if !tv.pushLine() {
+				break
+			}
+			t, _ := tv.lines.Back().Value.(*TextRecord)
+			if t.Values != nil {
+				last = tv.lines.Back()
+				if t.fileDataLine() >= n {
+					break
+				}
+			}
+			// we don't need to keep everything that we skip past
+			tv.topRow = last
+			tv.trimLines()
+		}
+		tv.centerOn(last)
+		tv.trimLines()
+		return true, false
+	}
+
+	// start from the closest checkpoint (or where we are)
+	cp := tv.txt.index.beforeData(n)
+	if t, _ := last.Value.(*TextRecord); t.fileDataLine() < n {
+		if pos := tv.txt.position(); pos.dataLineNum > cp.dataLineNum {
+			cp = pos
+		}
+	}
+
+	rd, err := tv.txt.cloneAt(cp)
+	if err != nil {
+		return false, false
+	}
+	defer rd.Close()
+
+	var t *TextRecord
+	for {
+		if cancel() {
+			return false, true
+		}
+		l, err := rd.ReadLine()
+		if err != nil {
+			if rd.isEOF {
+				tv.totalLines = rd.curDataLineNum
+			}
+			break
+		}
+		if l.Values == nil || l.fileLine() < tv.firstLine || !tv.keep(l) {
+			continue
+		}
+		t = l
+		if l.fileDataLine() >= n {
+			break
+		}
+	}
+	if t == nil {
+		// there aren't any more rows after the buffer
+		tv.centerOn(last)
+		return true, false
+	}
+
+	el := tv.jumpTo(t)
+	if el == nil {
+		return false, false
+	}
+	tv.showElement(el)
+	return true, false
+}
+
+// gotoPercent - go to a percentage of the way through the file (this needs the total number of lines)
+func (tv *TextPager) gotoPercent(pct int, cancel func() bool) (found bool, cancelled bool) {
+	pct = support.MaxInt(0, support.MinInt(pct, 100))
+
+	if tv.sortedInMemory() {
+		// this is the position in the sorted rows
+		el := tv.lines.Front()
+		for i := 0; i < (tv.lines.Len()-1)*pct/100; i++ {
+			el = el.Next()
+		}
+		tv.showElement(el)
+		return true, false
+	}
+	return tv.gotoRow(tv.totalLines*pct/100, cancel)
+}
+
+// readToEnd - read to the end of the file (to find the total number of lines). The file is read
+// in the background, while the progress is shown. Returns false if this was cancelled (Ctrl-C).
+func (tv *TextPager) readToEnd(events <-chan ui.Event, progress func(string)) bool {
+	if tv.totalLines > 0 {
+		return true
+	}
+
+	var rd *DelimitedTextFile
+	if tv.canReread {
+		var err error
+		rd, err = tv.txt.cloneAt(tv.txt.position())
+		if err != nil {
+			return false
+		}
+		defer rd.Close()
+	} else {
+		// lines from stdin can't be read again, so these are added to the buffer
+		rd = tv.txt
+	}
+
+	var size int64
+	if fi, err := os.Stat(rd.Filename); err == nil && rd.index != nil && rd.index.gzip == nil {
+		size = fi.Size()
+	}
+
+	var lineCount, offset int64
+	var stop int32
+	done := make(chan *list.List)
+
+	go func() {
+		// for stdin, we only keep the last lines
+		lines := list.New()
+		for atomic.LoadInt32(&stop) == 0 {
+			l, err := rd.ReadLine()
+			if err != nil {
+				break
+			}
+			if !tv.canReread && tv.keep(l) {
+				lines.PushBack(l)
+				if lines.Len() > maxLines {
+					lines.Remove(lines.Front())
+				}
+			}
+			atomic.StoreInt64(&lineCount, int64(rd.curDataLineNum))
+			atomic.StoreInt64(&offset, rd.offset)
+		}
+		done <- lines
+	}()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-events:
+			if e.ID == "<C-c>" || e.ID == "<Escape>" {
+				atomic.StoreInt32(&stop, 1)
+			}
+		case <-ticker.C:
+			msg := " Reading... " + addThousands(strconv.FormatInt(atomic.LoadInt64(&lineCount), 10)) + " lines"
+			if size > 0 {
+				msg += fmt.Sprintf(" (%d%%)", atomic.LoadInt64(&offset)*100/size)
+			}
+			progress(msg + " (Ctrl-C to cancel)")
+		case lines := <-done:
+			for el := lines.Front(); el != nil; el = el.Next() {
+				l, _ := el.Value.(*TextRecord)
+				l.Flag = tv.marks[l.LineNum]
+				tv.growColumns(l)
+				tv.lines.PushBack(l)
+			}
+			if atomic.LoadInt32(&stop) != 0 {
+				return false
+			}
+			if rd.isEOF {
+				tv.totalLines = rd.curDataLineNum
+			}
+			return tv.totalLines > 0
+		}
+	}
+}
+
+// halfPage - move the active row down (or up) half a page
+func (tv *TextPager) halfPage(down bool) {
+	n := support.MaxInt(1, (tv.visibleRows-3)/2)
+	for i := 0; i < n; i++ {
+		if down {
+			// make sure there are enough lines to move down
+			count := 0
+			for e := tv.topRow; e != nil && count <= tv.visibleRows; e = e.Next() {
+				count++
+			}
+			for ; count <= tv.visibleRows && tv.pushLine(); count++ {
+			}
+			tv.moveDown()
+		} else {
+			tv.moveUp()
+		}
+	}
+	tv.trimLines()
+}
+
+// statusLine - the current line and column (and the position in the file, if we know how long it is)
+func (tv *TextPager) statusLine() string {
+	var sb strings.Builder
+
+	t := tv.activeRecord()
+	if t != nil && t.Values != nil {
+		sb.WriteString(fmt.Sprintf(" line %d", t.DataLineNum))
+		if tv.totalLines > 0 {
+			sb.WriteString(fmt.Sprintf(" of %d (%d%%)", tv.totalLines, t.fileDataLine()*100/tv.totalLines))
+		}
+	}
+
+	if len(tv.colNames) > 0 {
+		col := tv.activeColumn()
+		sb.WriteString(fmt.Sprintf(" | column %d of %d (%s)", col+1, len(tv.colNames), tv.colNames[col]))
+	}
+
+	if tv.sortMode != sortNone {
+		sb.WriteString(" | sorted: " + tv.sortStatus())
+	}
+
+	if len(tv.filters) > 0 {
+		sb.WriteString(" | filter: " + tv.filterStatus())
+	}
+
+	if tv.count != "" {
+		sb.WriteString(" | " + tv.count)
+	}
+	return sb.String()
+}
@@ -0,0 +1,259 @@
+package textfile
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// writeGotoFile - write a temp file with a comment, a header, and rows numbered from 1
+func writeGotoFile(t *testing.T, rows int) string {
+	f, err := ioutil.TempFile("", "tabl-goto-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "# comment")
+	fmt.Fprintln(w, "n\tname")
+	for i := 1; i <= rows; i++ {
+		fmt.Fprintf(w, "%d\trow%d\n", i, i)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+// newGotoPager - a pager for a goto test file (10 rows are shown). For stdin, the file is
+// already open, so it can't be read again.
+func newGotoPager(t *testing.T, fname string, stdin bool) *TextPager {
+	txt := NewTabFile(fname)
+	if stdin {
+		f, err := os.Open(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txt.rd = f
+	}
+	tv := newTestPagerFile(t, txt)
+	tv.visibleRows = 13
+	if tv.canReread == stdin {
+		t.Fatalf("expected canReread to be %v", !stdin)
+	}
+	return tv
+}
+
+// pagerPosition - the line shown at the top, and the active line
+func pagerPosition(tv *TextPager) (top int, active int) {
+	if t, _ := tv.topRow.Value.(*TextRecord); t != nil {
+		top = t.DataLineNum
+	}
+	if t := tv.activeRecord(); t != nil {
+		active = t.DataLineNum
+	}
+	return top, active
+}
+
+func TestPagerGotoLine(t *testing.T) {
+	small := writeGotoFile(t, 50)
+	large := writeGotoFile(t, 30000)
+	never := func() bool { return false }
+	always := func() bool { return true }
+
+	tests := []struct {
+		fname     string
+		stdin     bool
+		n         int
+		cancel    func() bool
+		found     bool
+		cancelled bool
+		top       int
+		active    int
+	}{
+		{small, false, 1, never, true, false, 1, 1},
+		{small, false, 8, never, true, false, 1, 8},
+		{small, false, 30, never, true, false, 25, 30},
+		{small, false, 0, never, true, false, 1, 1},
+		{small, false, 1000, never, true, false, 45, 50},
+		{small, true, 30, never, true, false, 25, 30},
+		{small, true, 1000, never, true, false, 45, 50},
+		// past the rows that were loaded first
+		{large, false, 25000, never, true, false, 24995, 25000},
+		{large, false, 30000, never, true, false, 29995, 30000},
+		{large, false, 25000, always, false, true, 1, 1},
+		{large, true, 25000, never, true, false, 24995, 25000},
+		{large, true, 40000, never, true, false, 29995, 30000},
+		{large, true, 25000, always, false, true, 1, 1},
+	}
+
+	for _, test := range tests {
+		tv := newGotoPager(t, test.fname, test.stdin)
+		found, cancelled := tv.gotoLine(test.n, test.cancel)
+		if found != test.found || cancelled != test.cancelled {
+			t.Errorf("gotoLine(%d) (stdin: %v): got %v, %v, expected %v, %v", test.n, test.stdin, found, cancelled, test.found, test.cancelled)
+		}
+		if top, active := pagerPosition(tv); top != test.top || active != test.active {
+			t.Errorf("gotoLine(%d) (stdin: %v): got line %d at the top (active %d), expected %d (active %d)", test.n, test.stdin, top, active, test.top, test.active)
+		}
+		if tv.lines.Len() > maxLines {
+			t.Errorf("gotoLine(%d) (stdin: %v): %d lines in the buffer", test.n, test.stdin, tv.lines.Len())
+		}
+	}
+}
+
+func TestPagerGotoBack(t *testing.T) {
+	large := writeGotoFile(t, 30000)
+	never := func() bool { return false }
+
+	// the lines before the buffer are read again
+	tv := newGotoPager(t, large, false)
+	tv.gotoLine(25000, never)
+	if found, _ := tv.gotoLine(5, never); !found {
+		t.Fatalf("gotoLine(5): not found")
+	}
+	if top, active := pagerPosition(tv); top != 1 || active != 5 {
+		t.Errorf("expected line 5 (top 1), got line %d (top %d)", active, top)
+	}
+	if r := tv.activeRecord(); r.LineNum != 7 || r.Values[1] != "row5" {
+		t.Errorf("expected file line 7 (row5), got line %d (%v)", r.LineNum, r.Values)
+	}
+
+	// but stdin can't go back, so this is the first line we still have
+	tv = newGotoPager(t, large, true)
+	tv.gotoLine(25000, never)
+	first := tv.lines.Front().Value.(*TextRecord).DataLineNum
+	if first <= 5 {
+		t.Fatalf("expected the first lines to be removed from the buffer")
+	}
+	if found, _ := tv.gotoLine(5, never); !found {
+		t.Fatalf("gotoLine(5): not found")
+	}
+	if _, active := pagerPosition(tv); active != first {
+		t.Errorf("expected line %d, got line %d", first, active)
+	}
+}
+
+func TestPagerGotoPercent(t *testing.T) {
+	small := writeGotoFile(t, 50)
+	large := writeGotoFile(t, 30000)
+	never := func() bool { return false }
+
+	tests := []struct {
+		fname  string
+		stdin  bool
+		pct    int
+		active int
+	}{
+		{small, false, 0, 1},
+		{small, false, 50, 25},
+		{small, false, 100, 50},
+		{small, false, 150, 50},
+		{small, true, 50, 25},
+		{large, false, 50, 15000},
+		{large, false, 10, 3000},
+		{large, false, 100, 30000},
+		{large, true, 90, 27000},
+	}
+
+	for _, test := range tests {
+		tv := newGotoPager(t, test.fname, test.stdin)
+		if !tv.readToEnd(nil, func(string) {}) {
+			t.Fatalf("readToEnd: expected the total number of lines")
+		}
+		if found, _ := tv.gotoPercent(test.pct, never); !found {
+			t.Errorf("gotoPercent(%d) (stdin: %v): not found", test.pct, test.stdin)
+		}
+		if _, active := pagerPosition(tv); active != test.active {
+			t.Errorf("gotoPercent(%d) (stdin: %v): got line %d, expected %d", test.pct, test.stdin, active, test.active)
+		}
+	}
+}
+
+func TestPagerReadToEnd(t *testing.T) {
+	large := writeGotoFile(t, 30000)
+
+	for _, stdin := range []bool{false, true} {
+		tv := newGotoPager(t, large, stdin)
+		if !tv.readToEnd(nil, func(string) {}) {
+			t.Fatalf("stdin: %v: expected the total number of lines", stdin)
+		}
+		if tv.totalLines != 30000 {
+			t.Errorf("stdin: %v: expected 30000 lines, got %d", stdin, tv.totalLines)
+		}
+		// the file can still be read from where it was (or stdin was added to the buffer)
+		last := tv.lines.Back().Value.(*TextRecord).DataLineNum
+		if stdin && last != 30000 {
+			t.Errorf("stdin: expected the last line in the buffer, got %d", last)
+		} else if !stdin && (last != 10000 || !tv.pushLine()) {
+			t.Errorf("expected to still read from line 10000, got %d", last)
+		}
+		if top, active := pagerPosition(tv); top != 1 || active != 1 {
+			t.Errorf("stdin: %v: expected line 1 to be active, got %d (top %d)", stdin, active, top)
+		}
+	}
+}
+
+func TestPagerReadToEndCancel(t *testing.T) {
+	large := writeGotoFile(t, 30000)
+
+	for _, stdin := range []bool{false, true} {
+		tv := newGotoPager(t, large, stdin)
+
+		events := make(chan ui.Event, 1)
+		events <- ui.Event{ID: "<C-c>"}
+		if tv.readToEnd(events, func(string) {}) {
+			t.Errorf("stdin: %v: expected this to be cancelled", stdin)
+		}
+		if tv.totalLines != 0 {
+			t.Errorf("stdin: %v: expected the total to be unknown, got %d", stdin, tv.totalLines)
+		}
+		if top, active := pagerPosition(tv); top != 1 || active != 1 {
+			t.Errorf("stdin: %v: expected line 1 to be active, got %d (top %d)", stdin, active, top)
+		}
+	}
+}
+
+func TestPagerHalfPage(t *testing.T) {
+	small := writeGotoFile(t, 50)
+
+	tests := []struct {
+		down   bool
+		top    int
+		active int
+	}{
+		{true, 1, 6},
+		{true, 2, 11},
+		{true, 7, 16},
+		{false, 7, 11},
+		{false, 6, 6},
+		{false, 1, 1},
+		{false, 1, 1},
+	}
+
+	for _, stdin := range []bool{false, true} {
+		tv := newGotoPager(t, small, stdin)
+		for i, test := range tests {
+			tv.halfPage(test.down)
+			if top, active := pagerPosition(tv); top != test.top || active != test.active {
+				t.Errorf("stdin: %v: %d: got line %d at the top (active %d), expected %d (active %d)", stdin, i, top, active, test.top, test.active)
+			}
+		}
+	}
+
+	// at the end of the file
+	tv := newGotoPager(t, small, false)
+	tv.gotoLine(50, func() bool { return false })
+	tv.halfPage(true)
+	if _, active := pagerPosition(tv); active != 50 {
+		t.Errorf("expected line 50, got line %d", active)
+	}
+}
@@ -0,0 +1,181 @@
+package textfile
+
+import (
+	"container/list"
+
+	"github.com/mbreese/tabl/support"
+)
+
+// The pager keeps (at most) about maxLines lines in memory. For files that can be re-read, lines
+// that are removed from the buffer are read again as needed, starting from the closest checkpoint
+// (see checkpoint.go). For stdin (or multiple files), lines that are removed are gone for good.
+
+// pushLine - read the next (shown) line from the file and add it to the end of the buffer
+func (tv *TextPager) pushLine() bool {
+	for {
+		l, err := tv.txt.ReadLine()
+		if err != nil {
+			if tv.txt.isEOF {
+				tv.totalLines = tv.txt.curDataLineNum
+			}
+			return false
+		}
+		if !tv.keep(l) {
+			continue
+		}
+		l.Flag = tv.marks[l.LineNum]
+		tv.growColumns(l)
+		tv.lines.PushBack(l)
+		return true
+	}
+}
+
+// removeLine - remove a line from the buffer (keeping track of whether it was marked)
+func (tv *TextPager) removeLine(el *list.Element) {
+	if t, ok := el.Value.(*TextRecord); ok && t != nil {
+		if t.Flag {
+			tv.marks[t.LineNum] = true
+			if !tv.canReread {
+				// this line can't be read again, but we still need it to save the marked rows
+				tv.markedRecords[t.LineNum] = t
+			}
+		} else {
+			delete(tv.marks, t.LineNum)
+		}
+	}
+	tv.lines.Remove(el)
+	tv.evicted = true
+}
+
+// syncMarks - copy the marks for the lines in the buffer to tv.marks, so that it has all of the
+// marks (including lines that have been removed from the buffer)
+func (tv *TextPager) syncMarks() {
+	for el := tv.lines.Front(); el != nil; el = el.Next() {
+		t, _ := el.Value.(*TextRecord)
+		if t.Flag {
+			tv.marks[t.LineNum] = true
+		} else {
+			delete(tv.marks, t.LineNum)
+		}
+	}
+}
+
+// trimLines - remove lines from the front of the buffer (before the top row), so that we don't
+// keep the entire file in memory
+func (tv *TextPager) trimLines() {
+	for tv.lines.Len() > maxLines && tv.lines.Front() != tv.topRow {
+		tv.removeLine(tv.lines.Front())
+	}
+}
+
+// trimBack - remove lines from the end of the buffer (after the visible rows). The file is
+// moved back to the first line removed, so the lines will be read again when needed.
+func (tv *TextPager) trimBack() {
+	if !tv.canReread || tv.lines.Len() <= maxLines {
+		return
+	}
+
+	keep := tv.topRow
+	for i := 0; i < tv.visibleRows && keep.Next() != nil; i++ {
+		keep = keep.Next()
+	}
+	if tv.lines.Back() == keep {
+		return
+	}
+
+	el := tv.lines.Back()
+	for n := tv.lines.Len() - maxLines; n > 1 && el.Prev() != keep; n-- {
+		el = el.Prev()
+	}
+
+	t, _ := el.Value.(*TextRecord)
+	if err := tv.txt.seek(t.start); err != nil {
+		return
+	}
+	for el != nil {
+		next := el.Next()
+		tv.removeLine(el)
+		el = next
+	}
+}
+
+// loadBefore - read the lines before the front of the buffer again. Returns false if there aren't
+// any earlier lines (or they can't be read again).
+func (tv *TextPager) loadBefore() bool {
+	front := tv.lines.Front()
+	if !tv.canReread || front == nil {
+		return false
+	}
+	first, _ := front.Value.(*TextRecord)
+
+	// (if the rows are filtered, we may need to go back more than one checkpoint)
+	for end := first.fileLine(); end > tv.firstLine; {
+		cp := tv.txt.index.before(end)
+		rd, err := tv.txt.cloneAt(cp)
+		if err != nil {
+			return false
+		}
+
+		count := 0
+		for {
+			l, err := rd.ReadLine()
+			if err != nil || l.fileLine() >= end {
+				break
+			}
+			if l.fileLine() < tv.firstLine || !tv.keep(l) {
+				// comments before the first row (or filtered)
+				continue
+			}
+			l.Flag = tv.marks[l.LineNum]
+			tv.growColumns(l)
+			tv.lines.InsertBefore(l, front)
+			count++
+		}
+		rd.Close()
+
+		if count > 0 {
+			tv.trimBack()
+			return true
+		}
+		if cp.offset == 0 {
+			break
+		}
+		end = cp.lineNum + 1
+	}
+	return false
+}
+
+// jumpTo - start a new buffer at a line that was read again from the file (like a search match
+// that is past the end of the buffer). Returns the element for the line.
+func (tv *TextPager) jumpTo(t *TextRecord) *list.Element {
+	if err := tv.txt.seek(t.start); err != nil {
+		return nil
+	}
+	for el := tv.lines.Front(); el != nil; {
+		next := el.Next()
+		tv.removeLine(el)
+		el = next
+	}
+	if !tv.pushLine() {
+		return nil
+	}
+
+	el := tv.lines.Front()
+	tv.topRow = el
+	tv.activeRow = 1
+	tv.loadBefore()
+	tv.centerOn(el)
+	return el
+}
+
+// centerOn - make a row the active row, scrolling so that it is in the middle of the screen
+func (tv *TextPager) centerOn(el *list.Element) {
+	rows := support.MaxInt(1, tv.visibleRows-3)
+
+	tv.topRow = el
+	tv.activeRow = 1
+	for i := 0; i < rows/2 && tv.topRow.Prev() != nil; i++ {
+		tv.topRow = tv.topRow.Prev()
+		tv.activeRow++
+	}
+}
@@ -0,0 +1,301 @@
+package textfile
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mbreese/tabl/support"
+)
+
+// save formats (Tab in the save prompt goes through these in order)
+const (
+	saveOriginal = iota
+	saveTSV
+	saveCSV
+	saveJSON
+	saveMarkdown
+)
+
+var saveFormatNames = []string{"original", "tsv", "csv", "jsonl", "markdown"}
+
+// pagerSave is what to save: the format, the rows (marked rows, or all of the rows that are shown
+// with the current filters), and the columns (all, or only the columns that aren't hidden)
+type pagerSave struct {
+	format    int
+	marked    bool
+	shownCols bool
+}
+
+// describe - the save options, for the prompt
+func (s pagerSave) describe() string {
+	rows := "all rows"
+	if s.marked {
+		rows = "marked rows"
+	}
+	cols := "all columns"
+	if s.shownCols {
+		cols = "shown columns"
+	}
+	return rows + ", " + cols + ", " + saveFormatNames[s.format]
+}
+
+// markCount - the number of marked lines
+func (tv *TextPager) markCount() int {
+	tv.syncMarks()
+	return len(tv.marks)
+}
+
+// eachSavedRow - call f for each row to save, in order. If the file can be read again, all of the
+// rows are read again from the start. Otherwise (stdin), these are the rows in memory (and the
+// marked rows that have been removed from the buffer).
+func (tv *TextPager) eachSavedRow(marked bool, f func(*TextRecord) error) error {
+	tv.syncMarks()
+
+	if tv.canReread {
+		rd, err := tv.txt.cloneAt(checkpoint{})
+		if err != nil {
+			return err
+		}
+		defer rd.Close()
+
+		for {
+			l, err := rd.ReadLine()
+			if err != nil {
+				if rd.isEOF {
+					return nil
+				}
+				return err
+			}
+			if l.Values == nil || l.fileLine() < tv.firstLine {
+				continue
+			}
+			if (marked && tv.marks[l.LineNum]) || (!marked && tv.keep(l)) {
+				if err := f(l); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if marked {
+		lineNums := make([]int, 0, len(tv.markedRecords))
+		for n := range tv.markedRecords {
+			lineNums = append(lineNums, n)
+		}
+		sort.Ints(lineNums)
+		for _, n := range lineNums {
+			if err := f(tv.markedRecords[n]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for el := tv.lines.Front(); el != nil; el = el.Next() {
+		t, _ := el.Value.(*TextRecord)
+		if t.Values != nil && (t.Flag || !marked) {
+			if err := f(t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// saveToFile - save the marked rows (or all of the rows that are shown) to a file
+func (tv *TextPager) saveToFile(fname string, opts pagerSave) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	out := bufio.NewWriter(f)
+	if err := tv.writeRows(out, opts); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// writeRows - write the rows to save in a format
+func (tv *TextPager) writeRows(out io.Writer, opts pagerSave) error {
+	var cols []int
+	if opts.shownCols {
+		cols = tv.columns()
+	} else {
+		cols = make([]int, len(tv.colNames))
+		for i := range cols {
+			cols[i] = i
+		}
+	}
+
+	values := func(vals []string) []string {
+		ret := make([]string, len(cols))
+		for k, i := range cols {
+			if i < len(vals) {
+				ret[k] = vals[i]
+			}
+		}
+		return ret
+	}
+
+	eol := lineEnding(tv.txt.rawHeaderLine)
+	if eol == "" && tv.txt.IsCrLf {
+		eol = "\r\n"
+	} else if eol == "" {
+		eol = "\n"
+	}
+
+	switch opts.format {
+	case saveOriginal:
+		allCols := !opts.shownCols || len(cols) == len(tv.colNames)
+		err := tv.writeHeaderBlock(out, func() string {
+			if allCols && tv.txt.headerComment && tv.txt.lastComment != "" {
+				return tv.txt.lastComment
+			}
+			if allCols && tv.txt.rawHeaderLine != "" {
+				return tv.txt.rawHeaderLine
+			}
+			if tv.txt.headerComment {
+				return string(tv.txt.Comment) + tv.txt.joinLine(values(tv.colNames)) + eol
+			}
+			return tv.txt.joinLine(values(tv.colNames)) + eol
+		})
+		if err != nil {
+			return err
+		}
+		return tv.eachSavedRow(opts.marked, func(t *TextRecord) error {
+			if allCols {
+				_, err := io.WriteString(out, t.RawString)
+				if err == nil && !strings.HasSuffix(t.RawString, "\n") {
+					_, err = io.WriteString(out, eol)
+				}
+				return err
+			}
+			_, err := io.WriteString(out, tv.txt.joinLine(values(t.Values))+eol)
+			return err
+		})
+
+	case saveTSV, saveCSV:
+		join := joinFunc(opts.format)
+		err := tv.writeHeaderBlock(out, func() string {
+			return join(values(tv.colNames)) + "\n"
+		})
+		if err != nil {
+			return err
+		}
+		return tv.eachSavedRow(opts.marked, func(t *TextRecord) error {
+			_, err := io.WriteString(out, join(values(t.Values))+"\n")
+			return err
+		})
+
+	case saveJSON:
+		// one object per row (the values are all strings)
+		names := values(tv.colNames)
+		return tv.eachSavedRow(opts.marked, func(t *TextRecord) error {
+			var sb strings.Builder
+			sb.WriteString("{")
+			for i, v := range values(t.Values) {
+				if i > 0 {
+					sb.WriteString(",")
+				}
+				k, _ := json.Marshal(names[i])
+				val, _ := json.Marshal(v)
+				sb.Write(k)
+				sb.WriteString(":")
+				sb.Write(val)
+			}
+			sb.WriteString("}\n")
+			_, err := io.WriteString(out, sb.String())
+			return err
+		})
+
+	case saveMarkdown:
+		// the columns are lined up, so we need all of the rows first
+		style := &markdownStyle{}
+		styleCols := make([]StyleColumn, len(cols))
+		header := values(tv.colNames)
+		for i, name := range header {
+			header[i] = style.Escape(name)
+			styleCols[i].Width = support.StringWidth(header[i])
+		}
+
+		var rows [][]string
+		err := tv.eachSavedRow(opts.marked, func(t *TextRecord) error {
+			row := values(t.Values)
+			for i, v := range row {
+				row[i] = style.Escape(v)
+				styleCols[i].Width = support.MaxInt(styleCols[i].Width, support.StringWidth(row[i]))
+			}
+			rows = append(rows, row)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		writeRow := func(row []string) error {
+			cells := make([]string, len(row))
+			for i, v := range row {
+				cells[i] = support.PadRight(v, styleCols[i].Width) + " "
+			}
+			_, err := io.WriteString(out, style.Row(styleCols, cells)+"\n")
+			return err
+		}
+		if err := writeRow(header); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(out, style.HeaderSep(styleCols)+"\n"); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := writeRow(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// joinFunc - join values as a TSV or CSV line (without the line ending)
+func joinFunc(format int) func([]string) string {
+	if format == saveCSV {
+		return NewCSVFile("").joinLine
+	}
+	return func(vals []string) string {
+		quoted := make([]string, len(vals))
+		for i, v := range vals {
+			quoted[i] = quoteTab(v)
+		}
+		return strings.Join(quoted, "\t")
+	}
+}
+
+// writeHeaderBlock - write the comments before the header, the header, and then the comments
+// between the header and the first row (like TextSorter). With a commented header, the last
+// comment before the header is the header itself.
+func (tv *TextPager) writeHeaderBlock(out io.Writer, header func() string) error {
+	before := tv.commentsBefore
+	if tv.txt.headerComment && len(before) > 0 {
+		before = before[:len(before)-1]
+	}
+	for _, c := range before {
+		if _, err := io.WriteString(out, c.RawString); err != nil {
+			return err
+		}
+	}
+	if !tv.txt.noHeader {
+		if _, err := io.WriteString(out, header()); err != nil {
+			return err
+		}
+	}
+	for _, c := range tv.commentsAfter {
+		if _, err := io.WriteString(out, c.RawString); err != nil {
+			return err
+		}
+	}
+	return nil
+}
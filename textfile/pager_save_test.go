@@ -0,0 +1,99 @@
+package textfile
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const saveTestData = "# comment\nname\tnote\tn\na,b\tx|y\t1\nsay \"hi\"\tplain\t22\n"
+
+// writeSaveFile - write test data to a temp file
+func writeSaveFile(t *testing.T, data string) string {
+	f, err := ioutil.TempFile("", "tabl-save-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	_, err = f.WriteString(data)
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestPagerWriteRows(t *testing.T) {
+	data := saveTestData
+	fname := writeSaveFile(t, data)
+
+	tests := []struct {
+		opts     pagerSave
+		expected string
+	}{
+		{pagerSave{format: saveOriginal}, data},
+		{pagerSave{format: saveOriginal, shownCols: true}, "# comment\nname\tn\na,b\t1\nsay \"hi\"\t22\n"},
+		{pagerSave{format: saveTSV}, "# comment\nname\tnote\tn\na,b\tx|y\t1\nsay \"hi\"\tplain\t22\n"},
+		{pagerSave{format: saveCSV}, "# comment\nname,note,n\n\"a,b\",x|y,1\n\"say \"\"hi\"\"\",plain,22\n"},
+		{pagerSave{format: saveCSV, shownCols: true}, "# comment\nname,n\n\"a,b\",1\n\"say \"\"hi\"\"\",22\n"},
+		{pagerSave{format: saveJSON}, "{\"name\":\"a,b\",\"note\":\"x|y\",\"n\":\"1\"}\n{\"name\":\"say \\\"hi\\\"\",\"note\":\"plain\",\"n\":\"22\"}\n"},
+		{pagerSave{format: saveJSON, shownCols: true}, "{\"name\":\"a,b\",\"n\":\"1\"}\n{\"name\":\"say \\\"hi\\\"\",\"n\":\"22\"}\n"},
+		{pagerSave{format: saveMarkdown}, "| name     | note  | n  |\n|----------|-------|----|\n| a,b      | x\\|y  | 1  |\n| say \"hi\" | plain | 22 |\n"},
+		{pagerSave{format: saveMarkdown, shownCols: true}, "| name     | n  |\n|----------|----|\n| a,b      | 1  |\n| say \"hi\" | 22 |\n"},
+		{pagerSave{format: saveTSV, marked: true}, "# comment\nname\tnote\tn\nsay \"hi\"\tplain\t22\n"},
+	}
+
+	for _, test := range tests {
+		tv := newTestPager(t, fname)
+		tv.toggleColumn(1)
+		tv.lines.Back().Value.(*TextRecord).Flag = true
+
+		var sb strings.Builder
+		if err := tv.writeRows(&sb, test.opts); err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.opts.describe(), err)
+		}
+		if sb.String() != test.expected {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.opts.describe(), sb.String(), test.expected)
+		}
+	}
+}
+
+// limitWriter - a writer that fails after a number of bytes
+type limitWriter struct {
+	sb    strings.Builder
+	limit int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if w.sb.Len()+len(p) > w.limit {
+		return 0, errors.New("write failed")
+	}
+	return w.sb.Write(p)
+}
+
+func TestPagerWriteRowsError(t *testing.T) {
+	// (the last line doesn't end with a newline, so one is added)
+	fname := writeSaveFile(t, strings.TrimSuffix(saveTestData, "\n"))
+
+	for _, format := range []int{saveOriginal, saveTSV, saveCSV, saveJSON, saveMarkdown} {
+		opts := pagerSave{format: format}
+		tv := newTestPager(t, fname)
+
+		var sb strings.Builder
+		if err := tv.writeRows(&sb, opts); err != nil {
+			t.Fatalf("%s: unexpected error: %v", opts.describe(), err)
+		}
+
+		// every write is checked, so this fails wherever the writer stops
+		for limit := 0; limit < sb.Len(); limit++ {
+			if err := tv.writeRows(&limitWriter{limit: limit}, opts); err == nil {
+				t.Errorf("%s: expected an error after %d bytes", opts.describe(), limit)
+			}
+		}
+	}
+}
@@ -0,0 +1,489 @@
+package textfile
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mbreese/tabl/support"
+)
+
+// Case matching modes for searching
+const (
+	caseSmart = iota
+	caseIgnore
+	caseSensitive
+)
+
+var caseModeNames = []string{"smart-case", "ignore-case", "case-sensitive"}
+
+// pagerSearch is a search query in the pager. Queries are regular expressions, and can be limited
+// to a single column: "name:query" for a named column, or ":query" for the active column.
+type pagerSearch struct {
+	query string
+	re    *regexp.Regexp
+	col   int
+	whole bool
+}
+
+// newPagerSearch - parse a search query
+func newPagerSearch(query string, header []string, activeCol int, caseMode int, wholeCell bool) (*pagerSearch, error) {
+	ps := &pagerSearch{
+		query: query,
+		col:   -1,
+		whole: wholeCell,
+	}
+
+	pattern := query
+	if idx := strings.Index(query, ":"); idx == 0 {
+		ps.col = activeCol
+		pattern = query[1:]
+	} else if idx > 0 {
+		// only if this is a column name (otherwise the ':' is part of the query)
+		for i, name := range header {
+			if name == query[:idx] {
+				ps.col = i
+				pattern = query[idx+1:]
+				break
+			}
+		}
+	}
+
+	if pattern == "" {
+		return nil, fmt.Errorf("Missing search query")
+	}
+
+	if wholeCell {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	if caseMode == caseIgnore || (caseMode == caseSmart && strings.ToLower(pattern) == pattern) {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	ps.re = re
+	return ps, nil
+}
+
+// match - does the value for a column match?
+func (ps *pagerSearch) match(col int, v string) bool {
+	if ps.col >= 0 && col != ps.col {
+		return false
+	}
+	return ps.re.MatchString(v)
+}
+
+// highlight - mark the matches in the shown part of a value (which may be truncated to fit the column)
+func (ps *pagerSearch) highlight(col int, v string, shown string) string {
+	if ps.col >= 0 && col != ps.col {
+		return shown
+	}
+	if ps.whole {
+		if ps.re.MatchString(v) {
+			return string(matchStart) + shown + string(matchEnd)
+		}
+		return shown
+	}
+	return ps.re.ReplaceAllStringFunc(shown, func(m string) string {
+		if m == "" {
+			return m
+		}
+		return string(matchStart) + m + string(matchEnd)
+	})
+}
+
+// activeElement - the list element for the active row
+func (tv *TextPager) activeElement() *list.Element {
+	e := tv.topRow
+	for i := 0; e.Next() != nil && i < tv.activeRow-1; i++ {
+		e = e.Next()
+	}
+	return e
+}
+
+// allCols is used to search up to the last column of a line
+const allCols = int(^uint(0) >> 1)
+
+// findMatch - find the next (or previous) match for the current search, starting from the active row.
+// The search wraps around at the end (or start) of the file. Reading the rest of the file can take
+// a while, so the search stops early if cancel returns true.
+func (tv *TextPager) findMatch(back bool, cancel func() bool) (found bool, cancelled bool) {
+	start := tv.activeElement()
+	startCol := -1
+	if start == tv.matchRow {
+		startCol = tv.matchCol
+	}
+
+	if back {
+		// the start line (before the current match), and the buffer before it
+		if i := tv.findCol(start, 0, startCol-1, true); i >= 0 {
+			return tv.showMatch(start, i), false
+		}
+		for el := start.Prev(); el != nil; el = el.Prev() {
+			if i := tv.findCol(el, 0, allCols, true); i >= 0 {
+				return tv.showMatch(el, i), false
+			}
+		}
+
+		// the lines before the buffer
+		if tv.canReread {
+			first, _ := tv.lines.Front().Value.(*TextRecord)
+			t, col, cancelled := tv.scanBefore(first.fileLine(), tv.matchFunc(true), cancel)
+			if cancelled {
+				return false, true
+			}
+			if t != nil {
+				return tv.showMatch(tv.jumpTo(t), col), false
+			}
+		}
+
+		// wrap around to the end of the file
+		if tv.canReread {
+			t, col, cancelled := tv.scanFile(tv.txt.position(), -1, true, tv.matchFunc(true), cancel)
+			if cancelled {
+				return false, true
+			}
+			if t != nil {
+				return tv.showMatch(tv.jumpTo(t), col), false
+			}
+		} else {
+			for tv.pushLine() {
+				if cancel() {
+					return false, true
+				}
+			}
+		}
+		for el := tv.lines.Back(); el != start; el = el.Prev() {
+			if i := tv.findCol(el, 0, allCols, true); i >= 0 {
+				return tv.showMatch(el, i), false
+			}
+		}
+		if i := tv.findCol(start, startCol, allCols, true); i >= 0 {
+			return tv.showMatch(start, i), false
+		}
+		return false, false
+	}
+
+	// the start line (after the current match), and the buffer after it
+	if i := tv.findCol(start, startCol+1, allCols, false); i >= 0 {
+		return tv.showMatch(start, i), false
+	}
+	for el := start.Next(); el != nil; el = el.Next() {
+		if i := tv.findCol(el, 0, allCols, false); i >= 0 {
+			return tv.showMatch(el, i), false
+		}
+	}
+
+	// the rest of the file
+	if tv.canReread {
+		t, col, cancelled := tv.scanFile(tv.txt.position(), -1, false, tv.matchFunc(false), cancel)
+		if cancelled {
+			return false, true
+		}
+		if t != nil {
+			return tv.showMatch(tv.jumpTo(t), col), false
+		}
+	} else {
+		for tv.pushLine() {
+			if cancel() {
+				return false, true
+			}
+			if i := tv.findCol(tv.lines.Back(), 0, allCols, false); i >= 0 {
+				return tv.showMatch(tv.lines.Back(), i), false
+			}
+		}
+	}
+
+	// wrap around to the start of the file
+	first, _ := tv.lines.Front().Value.(*TextRecord)
+	if tv.canReread && first.fileLine() > tv.firstLine {
+		t, col, cancelled := tv.scanFile(checkpoint{}, first.fileLine(), false, tv.matchFunc(false), cancel)
+		if cancelled {
+			return false, true
+		}
+		if t != nil {
+			return tv.showMatch(tv.jumpTo(t), col), false
+		}
+	}
+	for el := tv.lines.Front(); el != start; el = el.Next() {
+		if i := tv.findCol(el, 0, allCols, false); i >= 0 {
+			return tv.showMatch(el, i), false
+		}
+	}
+	if i := tv.findCol(start, 0, startCol, false); i >= 0 {
+		return tv.showMatch(start, i), false
+	}
+	return false, false
+}
+
+// findCol - the first (or last, for back) matching column for a line between lo and hi, or -1
+func (tv *TextPager) findCol(el *list.Element, lo int, hi int, back bool) int {
+	t, ok := el.Value.(*TextRecord)
+	if !ok || t == nil {
+		return -1
+	}
+	return tv.findRecordCol(t, lo, hi, back)
+}
+
+func (tv *TextPager) findRecordCol(t *TextRecord, lo int, hi int, back bool) int {
+	if t.Values == nil {
+		return -1
+	}
+	lo = support.MaxInt(lo, 0)
+	hi = support.MinInt(hi, len(t.Values)-1)
+	for k := lo; k <= hi; k++ {
+		i := k
+		if back {
+			i = hi - (k - lo)
+		}
+		if tv.search.match(i, t.Values[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchFunc - find the matching column for a line (or -1)
+func (tv *TextPager) matchFunc(back bool) func(*TextRecord) int {
+	return func(t *TextRecord) int {
+		return tv.findRecordCol(t, 0, allCols, back)
+	}
+}
+
+// scanFile - search the (shown) lines in the file from a checkpoint up to a line (or the end of the
+// file if end < 0), without adding them to the buffer. match returns the matching column, or -1.
+// For a backward search, this is the last match.
+func (tv *TextPager) scanFile(cp checkpoint, end int, back bool, match func(*TextRecord) int, cancel func() bool) (*TextRecord, int, bool) {
+	rd, err := tv.txt.cloneAt(cp)
+	if err != nil {
+		return nil, -1, false
+	}
+	defer rd.Close()
+
+	var found *TextRecord
+	foundCol := -1
+	for {
+		if cancel() {
+			return nil, -1, true
+		}
+		l, err := rd.ReadLine()
+		if err != nil {
+			if rd.isEOF {
+				tv.totalLines = rd.curDataLineNum
+			}
+			break
+		}
+		if end >= 0 && l.fileLine() >= end {
+			break
+		}
+		if l.fileLine() < tv.firstLine || !tv.keep(l) {
+			continue
+		}
+		if i := match(l); i >= 0 {
+			if !back {
+				return l, i, false
+			}
+			found = l
+			foundCol = i
+		}
+	}
+	return found, foundCol, false
+}
+
+// scanBefore - search the lines before a line (going back one checkpoint at a time), for the
+// last match before the line
+func (tv *TextPager) scanBefore(lineNum int, match func(*TextRecord) int, cancel func() bool) (*TextRecord, int, bool) {
+	for end := lineNum; end > tv.firstLine; {
+		cp := tv.txt.index.before(end)
+		t, col, cancelled := tv.scanFile(cp, end, true, match, cancel)
+		if cancelled || t != nil {
+			return t, col, cancelled
+		}
+		if cp.offset == 0 {
+			break
+		}
+		end = cp.lineNum + 1
+	}
+	return nil, -1, false
+}
+
+// showMatch - make a match the active row and column
+func (tv *TextPager) showMatch(el *list.Element, col int) bool {
+	if el == nil {
+		return false
+	}
+	tv.matchRow = el
+	tv.matchCol = col
+	tv.showElement(el)
+	tv.showColumn(col)
+	return true
+}
+
+// showElement - make a row the active row, scrolling so that it is in the middle of the screen if
+// it isn't already visible
+func (tv *TextPager) showElement(el *list.Element) {
+	rows := support.MaxInt(1, tv.visibleRows-3)
+
+	e := tv.topRow
+	for i := 1; e != nil && i <= rows; i++ {
+		if e == el {
+			tv.activeRow = i
+			return
+		}
+		e = e.Next()
+	}
+	tv.centerOn(el)
+}
+
+// showColumn - scroll so that a column is visible (sticky columns are always visible, and hidden
+// columns can't be)
+func (tv *TextPager) showColumn(col int) {
+	if col >= len(tv.colSticky) || tv.colSticky[col] || tv.colHidden[col] {
+		return
+	}
+	for _, i := range tv.shownCols {
+		if i == col {
+			return
+		}
+	}
+	j := 0
+	for _, i := range tv.columns() {
+		if i == col {
+			break
+		}
+		if !tv.colSticky[i] {
+			j++
+		}
+	}
+	tv.leftCol = j
+}
+
+// matchCounts is the number of matches for a search in a file that can be read again. This is
+// counted by reading the entire file once, keeping the number of matches before each checkpoint,
+// so the position of a match can be found by reading from the checkpoint before it.
+type matchCounts struct {
+	search  *pagerSearch
+	total   int
+	before  []int
+	lineNum int
+	col     int
+	idx     int
+}
+
+// countRecordMatches - the number of matching columns in a line (up to a column)
+func (tv *TextPager) countRecordMatches(t *TextRecord, hi int) int {
+	count := 0
+	for i := 0; i < len(t.Values) && i <= hi; i++ {
+		if tv.search.match(i, t.Values[i]) {
+			count++
+		}
+	}
+	return count
+}
+
+// needsCount - do the matches for the current search need to be counted?
+func (tv *TextPager) needsCount() bool {
+	return tv.canReread && tv.search != nil && (tv.counts == nil || tv.counts.search != tv.search)
+}
+
+// countMatches - count the matches for the current search in the entire file (the lines that are
+// shown). This reads the file, so it stops early if cancel returns true.
+func (tv *TextPager) countMatches(cancel func() bool) bool {
+	if !tv.needsCount() {
+		return true
+	}
+	idx := tv.txt.index
+	mc := &matchCounts{search: tv.search, lineNum: -1}
+	count := func(t *TextRecord) int {
+		for len(mc.before) < len(idx.checkpoints) && idx.checkpoints[len(mc.before)].lineNum < t.fileLine() {
+			mc.before = append(mc.before, mc.total)
+		}
+		mc.total += tv.countRecordMatches(t, allCols)
+		return -1
+	}
+	if _, _, cancelled := tv.scanFile(checkpoint{}, -1, false, count, cancel); cancelled {
+		return false
+	}
+	for len(mc.before) < len(idx.checkpoints) {
+		mc.before = append(mc.before, mc.total)
+	}
+	tv.counts = mc
+	return true
+}
+
+// matchCounter - the position of the current match and the total number of matches. For a file
+// that can be read again, the matches are counted with countMatches. Otherwise (stdin), this is
+// only known if the entire file has been read (and is still in memory).
+func (tv *TextPager) matchCounter() (int, int, bool) {
+	if tv.search == nil {
+		return 0, 0, false
+	}
+
+	if tv.canReread {
+		mc := tv.counts
+		t, _ := tv.matchRow.Value.(*TextRecord)
+		if mc == nil || mc.search != tv.search || t == nil {
+			return 0, 0, false
+		}
+		if mc.lineNum != t.fileLine() || mc.col != tv.matchCol {
+			// the matches before this one: from the checkpoint before the line, and in the line
+			start := checkpoint{}
+			n := 0
+			if i := tv.txt.index.countBefore(t.fileLine()); i > 0 && i <= len(mc.before) {
+				start = tv.txt.index.checkpoints[i-1]
+				n = mc.before[i-1]
+			}
+			count := func(l *TextRecord) int {
+				n += tv.countRecordMatches(l, allCols)
+				return -1
+			}
+			tv.scanFile(start, t.fileLine(), false, count, func() bool { return false })
+			mc.lineNum = t.fileLine()
+			mc.col = tv.matchCol
+			mc.idx = n + tv.countRecordMatches(t, tv.matchCol)
+		}
+		return mc.idx, mc.total, true
+	}
+
+	if !tv.txt.isEOF || tv.evicted {
+		return 0, 0, false
+	}
+	idx := 0
+	total := 0
+	for el := tv.lines.Front(); el != nil; el = el.Next() {
+		line, ok := el.Value.(*TextRecord)
+		if !ok || line == nil || line.Values == nil {
+			continue
+		}
+		for i, v := range line.Values {
+			if tv.search.match(i, v) {
+				total++
+				if el == tv.matchRow && i == tv.matchCol {
+					idx = total
+				}
+			}
+		}
+	}
+	return idx, total, true
+}
+
+// matchStatus - describe the current match
+func (tv *TextPager) matchStatus() string {
+	t, _ := tv.matchRow.Value.(*TextRecord)
+	name := fmt.Sprintf("column %d", tv.matchCol+1)
+	if tv.matchCol < len(tv.colNames) && tv.colNames[tv.matchCol] != "" {
+		name = tv.colNames[tv.matchCol]
+	}
+	status := fmt.Sprintf(" Match: line %d, %s", t.DataLineNum, name)
+	if idx, total, ok := tv.matchCounter(); ok {
+		status += fmt.Sprintf(" (match %d of %d)", idx, total)
+	}
+	if tv.searchBack {
+		status += " [backward]"
+	}
+	return status
+}
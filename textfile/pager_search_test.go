@@ -0,0 +1,113 @@
+package textfile
+
+import (
+	"testing"
+)
+
+func TestPagerSearch(t *testing.T) {
+	header := []string{"name", "value", "note"}
+
+	tests := []struct {
+		query     string
+		caseMode  int
+		wholeCell bool
+		col       int
+		value     string
+		match     bool
+	}{
+		// any column
+		{"abc", caseSmart, false, 0, "xabcx", true},
+		{"abc", caseSmart, false, 2, "xabcx", true},
+		{"a.c", caseSmart, false, 1, "abc", true},
+		{"abc", caseSmart, false, 1, "ab", false},
+
+		// a named column
+		{"value:abc", caseSmart, false, 1, "abc", true},
+		{"value:abc", caseSmart, false, 0, "abc", false},
+		{"value:abc", caseSmart, false, 2, "abc", false},
+
+		// the active column (1)
+		{":abc", caseSmart, false, 1, "abc", true},
+		{":abc", caseSmart, false, 0, "abc", false},
+
+		// not a column name, so the ':' is part of the query
+		{"other:abc", caseSmart, false, 0, "other:abc", true},
+		{"other:abc", caseSmart, false, 0, "abc", false},
+		{"12:30", caseSmart, false, 2, "at 12:30", true},
+
+		// smart case
+		{"abc", caseSmart, false, 0, "ABC", true},
+		{"Abc", caseSmart, false, 0, "abc", false},
+		{"Abc", caseSmart, false, 0, "Abc", true},
+		{"value:abc", caseSmart, false, 1, "ABC", true},
+
+		// ignore case
+		{"Abc", caseIgnore, false, 0, "aBC", true},
+
+		// case sensitive
+		{"abc", caseSensitive, false, 0, "ABC", false},
+		{"abc", caseSensitive, false, 0, "abc", true},
+
+		// whole cell
+		{"abc", caseSmart, true, 0, "abc", true},
+		{"abc", caseSmart, true, 0, "abcd", false},
+		{"a|abc", caseSmart, true, 0, "abc", true},
+		{"a|abc", caseSmart, true, 0, "ab", false},
+		{"value:1", caseSmart, true, 1, "1", true},
+		{"value:1", caseSmart, true, 1, "10", false},
+	}
+
+	for _, test := range tests {
+		ps, err := newPagerSearch(test.query, header, 1, test.caseMode, test.wholeCell)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.query, err)
+			continue
+		}
+		if got := ps.match(test.col, test.value); got != test.match {
+			t.Errorf("%q (case: %s, whole: %v): match(%d, %q) = %v, expected %v", test.query, caseModeNames[test.caseMode], test.wholeCell, test.col, test.value, got, test.match)
+		}
+	}
+}
+
+func TestPagerSearchErrors(t *testing.T) {
+	header := []string{"name", "value"}
+
+	for _, query := range []string{"", ":", "value:", "a[b"} {
+		if _, err := newPagerSearch(query, header, 0, caseSmart, false); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
+
+func TestPagerSearchHighlight(t *testing.T) {
+	header := []string{"name", "value"}
+	m := func(s string) string {
+		return string(matchStart) + s + string(matchEnd)
+	}
+
+	tests := []struct {
+		query     string
+		wholeCell bool
+		col       int
+		value     string
+		shown     string
+		expected  string
+	}{
+		{"b", false, 0, "abcb", "abcb", "a" + m("b") + "c" + m("b")},
+		{"value:b", false, 0, "abc", "abc", "abc"},
+		{"value:b", false, 1, "abc", "abc", "a" + m("b") + "c"},
+		{"b*", false, 0, "ac", "ac", "ac"},
+		{"abcdef", true, 0, "abcdef", "abc…", m("abc…")},
+		{"abc", true, 0, "abcdef", "abc…", "abc…"},
+	}
+
+	for _, test := range tests {
+		ps, err := newPagerSearch(test.query, header, 0, caseSmart, test.wholeCell)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.query, err)
+		}
+		if got := ps.highlight(test.col, test.value, test.shown); got != test.expected {
+			t.Errorf("%q: highlight(%d, %q, %q) = %q, expected %q", test.query, test.col, test.value, test.shown, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package textfile
+
+import (
+	"container/list"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/mbreese/tabl/support"
+)
+
+// The pager can sort the rows by a column. If the file can be read again, it is sorted (with
+// TextSorter) into a temp file, and the pager shows the temp file instead. Otherwise (stdin), the
+// rows in memory are sorted, which only works if all of the rows fit in memory. The temp file keeps
+// the original line numbers (see TextSorter.withLineNums), so that marks, searches, and ":N" still
+// refer to the lines in the original file.
+
+// sort modes (the 'o' key goes through these in order)
+const (
+	sortNone = iota
+	sortAsc
+	sortDesc
+	sortNumAsc
+	sortNumDesc
+)
+
+var sortModeNames = []string{"unsorted", "ascending", "descending", "numeric, ascending", "numeric, descending"}
+
+// sortIndicators are added to the name of the sorted column
+var sortIndicators = []string{"", " ▲", " ▼", " #▲", " #▼"}
+
+// nextSort - sort by a column, or if it is already sorted, go to the next sort mode
+func (tv *TextPager) nextSort(col int, events <-chan ui.Event, progress func(string)) error {
+	if col != tv.sortCol || tv.sortMode == sortNone {
+		return tv.sortBy(col, sortAsc, events, progress)
+	}
+	return tv.sortBy(col, (tv.sortMode+1)%len(sortModeNames), events, progress)
+}
+
+// sortBy - sort the rows by a column (or put them back in file order, for sortNone). A file is
+// sorted in the background, while the progress is shown (see sortToFile).
+func (tv *TextPager) sortBy(col int, mode int, events <-chan ui.Event, progress func(string)) error {
+	src := tv.source
+	if src == nil {
+		src = tv.txt
+	}
+
+	rd, err := src.reopen()
+	if err != nil {
+		err = tv.sortBuffer(col, mode)
+	} else if mode == sortNone {
+		// back to the original file
+		err = tv.openFile(rd)
+		if err == nil {
+			tv.source = nil
+			tv.removeSortFile()
+		}
+	} else {
+		err = tv.sortToFile(rd, col, mode, events, progress)
+	}
+	if err != nil {
+		return err
+	}
+
+	tv.sortCol = col
+	tv.sortMode = mode
+	if mode != sortNone {
+		name := tv.colNames[col] + sortIndicators[mode]
+		tv.colWidth[col] = support.MaxInt(tv.colWidth[col], support.StringWidth(name+" "))
+	}
+	return nil
+}
+
+// sortColumns - the columns to sort by
+func sortColumns(col int, mode int) []*TextColumn {
+	c := NewIndexColumn(col)
+	if mode == sortNumAsc || mode == sortNumDesc {
+		c.AsNumber()
+	}
+	if mode == sortDesc || mode == sortNumDesc {
+		c.AsReverse()
+	}
+	return []*TextColumn{c}
+}
+
+// sortToFile - sort the file into a temp file, and show that instead. The file is sorted in the
+// background, while the progress is shown. Returns errCancelled if this was cancelled (Ctrl-C).
+func (tv *TextPager) sortToFile(rd *DelimitedTextFile, col int, mode int, events <-chan ui.Event, progress func(string)) error {
+	f, err := ioutil.TempFile("", "tabl_pager")
+	if err != nil {
+		rd.Close()
+		return err
+	}
+
+	var rows, writing, stop int32
+	sorter := NewTextSorter(rd, sortColumns(col, mode)).
+		WithStable(true).
+		withLineNums(true).
+		withProgress(func(n int, w bool) bool {
+			atomic.StoreInt32(&rows, int32(n))
+			if w {
+				atomic.StoreInt32(&writing, 1)
+			}
+			return atomic.LoadInt32(&stop) == 0
+		})
+
+	done := make(chan error)
+	go func() {
+		// (the sorter closes rd)
+		done <- sorter.WriteFile(f)
+	}()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-events:
+			if e.ID == "<C-c>" || e.ID == "<Escape>" {
+				atomic.StoreInt32(&stop, 1)
+			}
+		case <-ticker.C:
+			msg := " Sorting... "
+			if atomic.LoadInt32(&writing) != 0 {
+				msg += "writing "
+			}
+			progress(msg + addThousands(strconv.Itoa(int(atomic.LoadInt32(&rows)))) + " rows (Ctrl-C to cancel)")
+		case err := <-done:
+			f.Close()
+			if err != nil {
+				os.Remove(f.Name())
+				return err
+			}
+			return tv.showSorted(rd, f.Name())
+		}
+	}
+}
+
+// showSorted - show a sorted temp file (with the original line numbers)
+func (tv *TextPager) showSorted(rd *DelimitedTextFile, filename string) error {
+	sorted := rd.Clone(filename).
+		WithNoHeader(rd.noHeader).
+		WithHeaderComment(rd.headerComment).
+		withLineNums(true)
+	if err := tv.openFile(sorted); err != nil {
+		os.Remove(filename)
+		return err
+	}
+	tv.removeSortFile()
+	tv.sortFile = filename
+	return nil
+}
+
+// openFile - show a different file in the pager (the sorted temp file, or the original file
+// again). The original file is kept as the source (for sorting again). Marked rows are kept,
+// because the sorted file has the original line numbers.
+func (tv *TextPager) openFile(txt *DelimitedTextFile) error {
+	tv.syncMarks()
+	tv.txt.Close()
+	tv.txt.index.close()
+	if tv.source == nil {
+		tv.source = tv.txt
+	}
+
+	tv.txt = txt
+	tv.lines = list.New()
+	tv.matchRow = nil
+	tv.counts = nil
+	tv.evicted = false
+	tv.totalLines = 0
+	tv.load()
+	tv.activeRow = 1
+	if tv.lines.Len() == 0 {
+		return errors.New("No rows")
+	}
+
+	if len(tv.filters) > 0 {
+		tv.refilter(func() bool { return false })
+	}
+	return nil
+}
+
+// sortBuffer - sort the rows in memory. This needs all of the rows, so we read to the end of the
+// file first (if there are too many rows, they can't be sorted). Comments are at the end (until the
+// rows are back in file order).
+func (tv *TextPager) sortBuffer(col int, mode int) error {
+	for !tv.evicted && tv.lines.Len() <= maxLines && tv.pushLine() {
+	}
+	if tv.evicted || !tv.txt.isEOF {
+		return errors.New("Too many rows to sort (stdin can't be read again)")
+	}
+
+	active := tv.activeElement()
+	els := make([]*list.Element, 0, tv.lines.Len())
+	for el := tv.lines.Front(); el != nil; el = el.Next() {
+		els = append(els, el)
+	}
+
+	cols := sortColumns(col, mode)
+	sort.SliceStable(els, func(i, j int) bool {
+		one, _ := els[i].Value.(*TextRecord)
+		two, _ := els[j].Value.(*TextRecord)
+		if mode != sortNone {
+			if (one.Values == nil) != (two.Values == nil) {
+				return two.Values == nil
+			}
+			if c := compareRecords(cols, one, two); c != 0 {
+				return c < 0
+			}
+		}
+		return one.LineNum < two.LineNum
+	})
+
+	// (moving the elements keeps the same elements for the active row and search match)
+	for _, el := range els {
+		tv.lines.MoveToBack(el)
+	}
+	tv.centerOn(active)
+	return nil
+}
+
+// sortedInMemory - are the rows in memory sorted (instead of in file order)?
+func (tv *TextPager) sortedInMemory() bool {
+	return tv.sortMode != sortNone && tv.sortFile == ""
+}
+
+// sortStatus - the current sort column (and mode)
+func (tv *TextPager) sortStatus() string {
+	return tv.colNames[tv.sortCol] + " (" + sortModeNames[tv.sortMode] + ")"
+}
+
+// removeSortFile - remove the sorted temp file (if there is one)
+func (tv *TextPager) removeSortFile() {
+	if tv.sortFile != "" {
+		os.Remove(tv.sortFile)
+		tv.sortFile = ""
+	}
+}
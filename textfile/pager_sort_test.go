@@ -0,0 +1,103 @@
+package textfile
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// pagerNames - the names (last column) of the rows in the buffer, in order
+func pagerNames(tv *TextPager) string {
+	var names []string
+	for el := tv.lines.Front(); el != nil; el = el.Next() {
+		if r, _ := el.Value.(*TextRecord); r.Values != nil {
+			names = append(names, r.Values[len(r.Values)-1])
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func TestPagerSortBuffer(t *testing.T) {
+	tv := newTestPager(t, "testdata/sort_ties.txt")
+
+	tests := []struct {
+		col      int
+		mode     int
+		expected string
+	}{
+		{0, sortAsc, "second,fourth,seventh,first,third,fifth,sixth"},
+		{0, sortDesc, "sixth,first,third,fifth,second,fourth,seventh"},
+		{1, sortNumAsc, "third,sixth,first,seventh,fourth,fifth,second"},
+		{1, sortNumDesc, "second,fifth,fourth,first,seventh,third,sixth"},
+		{2, sortAsc, "fifth,first,fourth,second,seventh,sixth,third"},
+		{0, sortNone, "first,second,third,fourth,fifth,sixth,seventh"},
+	}
+
+	for _, test := range tests {
+		active := tv.activeElement()
+		if err := tv.sortBuffer(test.col, test.mode); err != nil {
+			t.Fatalf("sortBuffer(%d, %s): unexpected error: %v", test.col, sortModeNames[test.mode], err)
+		}
+		if got := pagerNames(tv); got != test.expected {
+			t.Errorf("sortBuffer(%d, %s): got %s, expected %s", test.col, sortModeNames[test.mode], got, test.expected)
+		}
+		if tv.activeElement() != active {
+			t.Errorf("sortBuffer(%d, %s): the active row changed", test.col, sortModeNames[test.mode])
+		}
+	}
+}
+
+func TestPagerSortFile(t *testing.T) {
+	tv := newTestPager(t, "testdata/sort_ties.txt")
+	defer tv.removeSortFile()
+
+	// mark the second row ("a", "second")
+	tv.lines.Front().Next().Value.(*TextRecord).Flag = true
+
+	if err := tv.sortBy(1, sortNumDesc, nil, func(string) {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tv.sortFile == "" {
+		t.Fatalf("expected a sorted temp file")
+	}
+	if got, expected := pagerNames(tv), "second,fifth,fourth,first,seventh,third,sixth"; got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+
+	// the rows keep the line numbers from the original file
+	first := tv.lines.Front().Value.(*TextRecord)
+	if first.LineNum != 4 || first.DataLineNum != 2 || first.RawString != "a\t5\tsecond\n" {
+		t.Errorf("expected line 4 (data line 2), got line %d (data line %d): %q", first.LineNum, first.DataLineNum, first.RawString)
+	}
+	if !first.Flag {
+		t.Errorf("expected the marked row to still be marked")
+	}
+
+	if found, _ := tv.gotoLine(6, func() bool { return false }); !found {
+		t.Fatalf("gotoLine(6): not found")
+	}
+	if r := tv.activeRecord(); r.Values[2] != "sixth" {
+		t.Errorf("gotoLine(6): expected sixth, got %v", r.Values)
+	}
+
+	var saved []string
+	tv.eachSavedRow(true, func(r *TextRecord) error {
+		saved = append(saved, r.Values[2])
+		return nil
+	})
+	if strings.Join(saved, ",") != "second" {
+		t.Errorf("expected the marked row to be saved, got %v", saved)
+	}
+
+	// back to the original file
+	sortFile := tv.sortFile
+	if err := tv.sortBy(1, sortNone, nil, func(string) {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, expected := pagerNames(tv), "first,second,third,fourth,fifth,sixth,seventh"; got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+	if _, err := os.Stat(sortFile); !os.IsNotExist(err) {
+		t.Errorf("expected the temp file to be removed")
+	}
+}
@@ -0,0 +1,172 @@
+package textfile
+
+import (
+	"image"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// These mark the start and end of a search match in a table cell. (They are in the unicode
+// private use area, so they shouldn't be in any values.)
+const (
+	matchStart = '\uE000'
+	matchEnd   = '\uE001'
+)
+
+var matchStyle ui.Style = ui.NewStyle(ui.ColorYellow, ui.ColorClear, ui.ModifierBold)
+var statusStyle ui.Style = ui.NewStyle(ui.ColorClear, ui.ColorClear, ui.ModifierReverse)
+
+// pagerTable is a termui table that doesn't parse the values for termui's [text](style) markup,
+// so values with brackets are shown as-is. Search matches are marked with matchStart/matchEnd.
+type pagerTable struct {
+	*widgets.Table
+	Status string
+}
+
+func newPagerTable() *pagerTable {
+	return &pagerTable{Table: widgets.NewTable()}
+}
+
+// styledCells - convert a value to cells, highlighting any marked matches
+func styledCells(s string, style ui.Style) []ui.Cell {
+	cells := make([]ui.Cell, 0, len(s))
+	cur := style
+	for _, r := range s {
+		switch r {
+		case matchStart:
+			cur = matchStyle
+			cur.Bg = style.Bg
+		case matchEnd:
+			cur = style
+		default:
+			cells = append(cells, ui.NewCell(r, cur))
+		}
+	}
+	return cells
+}
+
+// Draw - draw the table (this is the same as widgets.Table.Draw for left-aligned tables, except for the styles)
+// and the status line (on the last line)
+func (t *pagerTable) Draw(buf *ui.Buffer) {
+	t.Block.Draw(buf)
+
+	if t.Status != "" {
+		// (this is below the inner area of the table, so it doesn't overlap the rows)
+		y := t.Max.Y - 1
+		buf.Fill(ui.NewCell(' ', statusStyle), image.Rect(t.Min.X, y, t.Max.X, y+1))
+		for _, cx := range ui.BuildCellWithXArray(styledCells(t.Status, statusStyle)) {
+			if t.Min.X+cx.X >= t.Max.X {
+				break
+			}
+			buf.SetCell(cx.Cell, image.Pt(t.Min.X+cx.X, y))
+		}
+	}
+
+	columnWidths := t.ColumnWidths
+	if len(columnWidths) == 0 && len(t.Rows) > 0 {
+		columnCount := len(t.Rows[0])
+		for i := 0; i < columnCount; i++ {
+			columnWidths = append(columnWidths, t.Inner.Dx()/columnCount)
+		}
+	}
+
+	y := t.Inner.Min.Y
+	for i := 0; i < len(t.Rows) && y < t.Inner.Max.Y; i++ {
+		row := t.Rows[i]
+		x := t.Inner.Min.X
+
+		rowStyle := t.TextStyle
+		if style, ok := t.RowStyles[i]; ok {
+			rowStyle = style
+		}
+
+		if t.FillRow {
+			buf.Fill(ui.NewCell(' ', rowStyle), image.Rect(t.Inner.Min.X, y, t.Inner.Max.X, y+1))
+		}
+
+		for j := 0; j < len(row) && j < len(columnWidths); j++ {
+			for _, cx := range ui.BuildCellWithXArray(styledCells(row[j], rowStyle)) {
+				k, cell := cx.X, cx.Cell
+				if k >= columnWidths[j] || x+k >= t.Inner.Max.X {
+					cell.Rune = ui.ELLIPSES
+					buf.SetCell(cell, image.Pt(x+k-1, y))
+					break
+				}
+				buf.SetCell(cell, image.Pt(x+k, y))
+			}
+			x += columnWidths[j] + 1
+		}
+
+		sepX := t.Inner.Min.X
+		sep := ui.NewCell(ui.VERTICAL_LINE, t.Block.BorderStyle)
+		for j, width := range columnWidths {
+			if t.FillRow && j < len(columnWidths)-1 {
+				sep.Style.Bg = rowStyle.Bg
+			} else {
+				sep.Style.Bg = t.Block.BorderStyle.Bg
+			}
+			sepX += width
+			buf.SetCell(sep, image.Pt(sepX, y))
+			sepX++
+		}
+
+		y++
+	}
+}
+
+// pagerList is a termui list that doesn't parse the rows for termui's [text](style) markup, so
+// column names (and values) with brackets are shown as-is
+type pagerList struct {
+	*widgets.List
+	topRow int
+}
+
+func newPagerList() *pagerList {
+	return &pagerList{List: widgets.NewList()}
+}
+
+// Draw - draw the list (this is the same as widgets.List.Draw without wrapping, except for the styles)
+func (l *pagerList) Draw(buf *ui.Buffer) {
+	l.Block.Draw(buf)
+
+	// keep the selected row in view
+	if l.SelectedRow >= l.Inner.Dy()+l.topRow {
+		l.topRow = l.SelectedRow - l.Inner.Dy() + 1
+	} else if l.SelectedRow < l.topRow {
+		l.topRow = l.SelectedRow
+	}
+
+	y := l.Inner.Min.Y
+	for row := l.topRow; row < len(l.Rows) && y < l.Inner.Max.Y; row++ {
+		style := l.TextStyle
+		if row == l.SelectedRow {
+			style = l.SelectedRowStyle
+		}
+		for _, cx := range ui.BuildCellWithXArray(styledCells(l.Rows[row], style)) {
+			if cx.X >= l.Inner.Dx() {
+				buf.SetCell(ui.NewCell(ui.ELLIPSES, style), image.Pt(l.Inner.Max.X-1, y))
+				break
+			}
+			buf.SetCell(cx.Cell, image.Pt(l.Inner.Min.X+cx.X, y))
+		}
+		y++
+	}
+
+	if l.topRow > 0 {
+		buf.SetCell(ui.NewCell(ui.UP_ARROW, ui.NewStyle(ui.ColorWhite)), image.Pt(l.Inner.Max.X-1, l.Inner.Min.Y))
+	}
+	if len(l.Rows) > l.topRow+l.Inner.Dy() {
+		buf.SetCell(ui.NewCell(ui.DOWN_ARROW, ui.NewStyle(ui.ColorWhite)), image.Pt(l.Inner.Max.X-1, l.Inner.Max.Y-1))
+	}
+}
+
+// ScrollPageUp - select the top row that is shown, or if it is already selected, go up a page (like
+// widgets.List.ScrollPageUp, which doesn't know the top row that Draw uses here)
+func (l *pagerList) ScrollPageUp() {
+	if l.SelectedRow > l.topRow {
+		l.SelectedRow = l.topRow
+	} else {
+		l.ScrollAmount(-l.Inner.Dy())
+	}
+}
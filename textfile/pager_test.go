@@ -0,0 +1,20 @@
+package textfile
+
+import "testing"
+
+// newTestPager - a pager for a file (without a screen)
+func newTestPager(t *testing.T, fname string) *TextPager {
+	return newTestPagerFile(t, NewTabFile(fname))
+}
+
+// newTestPagerFile - a pager for an open file (without a screen)
+func newTestPagerFile(t *testing.T, txt *DelimitedTextFile) *TextPager {
+	tv := NewTextPager(txt)
+	tv.visibleRows = 20
+	tv.visibleCols = 80
+	tv.load()
+	if tv.lines.Len() == 0 {
+		t.Fatalf("%s: no rows", txt.Filename)
+	}
+	return tv
+}
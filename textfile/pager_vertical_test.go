@@ -0,0 +1,32 @@
+package textfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPagerVertical(t *testing.T) {
+	tv := newTestPager(t, "testdata/view_wrap.txt")
+	tv.visibleCols = 30
+	tv.activeRecord().Values[0] = "[1](fg:red)"
+
+	p := newPagerList()
+	p.SetRect(0, 0, tv.visibleCols, tv.visibleRows)
+	p.SelectedRowStyle = p.TextStyle
+	tv.updateVertical(p)
+
+	// the values are shown as-is (not as termui markup), and wrapped to fit
+	expected := []string{
+		"id   | [1](fg:red)",
+		"desc | The quick brown fox",
+		"     | jumps over the lazy",
+		"     | dog",
+		"n    | 5",
+	}
+	if got := drawnLines(p, p.Inner)[:len(expected)]; strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if p.Title != " Row 1 (v to go back) " {
+		t.Errorf("unexpected title: %q", p.Title)
+	}
+}
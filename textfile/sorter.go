@@ -2,12 +2,15 @@ package textfile
 
 import (
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 var defaultSortBufferLen int = 10000
@@ -18,8 +21,16 @@ type TextSorter struct {
 	cols          []*TextColumn
 	showComments  bool
 	sortBufferLen int
+	stable        bool
+	unique        bool
+	eol           string
+	lineNums      bool
+	progress      func(rows int, writing bool) bool
 }
 
+// errCancelled is returned if a sort is stopped (see withProgress)
+var errCancelled = errors.New("Cancelled")
+
 // NewTextSorter - create a new text sorter
 func NewTextSorter(f *DelimitedTextFile, cols []*TextColumn) *TextSorter {
 	return &TextSorter{
@@ -27,6 +38,8 @@ func NewTextSorter(f *DelimitedTextFile, cols []*TextColumn) *TextSorter {
 		cols:          cols,
 		showComments:  false,
 		sortBufferLen: defaultSortBufferLen,
+		stable:        false,
+		unique:        false,
 	}
 }
 
@@ -36,6 +49,38 @@ func (tes *TextSorter) WithShowComments(b bool) *TextSorter {
 	return tes
 }
 
+// WithStable - rows with equal keys are kept in their original input order
+func (tes *TextSorter) WithStable(b bool) *TextSorter {
+	tes.stable = b
+	return tes
+}
+
+// WithUnique - only write the first row (in input order) for each key
+func (tes *TextSorter) WithUnique(b bool) *TextSorter {
+	tes.unique = b
+	return tes
+}
+
+// withLineNums - write the original line numbers at the start of each row (see writeTempLine),
+// so that they can be read back with DelimitedTextFile.withLineNums
+func (tes *TextSorter) withLineNums(b bool) *TextSorter {
+	tes.lineNums = b
+	return tes
+}
+
+// withProgress - call f with the number of rows read (and then written) for each row. If f returns
+// false, the sort is stopped (and WriteFile returns errCancelled).
+func (tes *TextSorter) withProgress(f func(rows int, writing bool) bool) *TextSorter {
+	tes.progress = f
+	return tes
+}
+
+// WithSortBufferLen - set the number of rows to sort in memory before writing a temp file (default 10000)
+func (tes *TextSorter) WithSortBufferLen(i int) *TextSorter {
+	tes.sortBufferLen = i
+	return tes
+}
+
 // WriteFile - sort and write the new columns to the given stream
 func (tes *TextSorter) WriteFile(out io.Writer) error {
 
@@ -49,6 +94,14 @@ func (tes *TextSorter) WriteFile(out io.Writer) error {
 	var line *TextRecord
 	var err error = nil
 	wroteHeader := false
+	read := 0
+
+	// comments before the first data line are held until we know what the header is
+	var commentsBefore []*TextRecord
+	var commentsAfter []*TextRecord
+
+	// unique needs ties in input order so that the first row for a key is kept
+	stable := tes.stable || tes.unique
 
 	records := make(TextSortRecords, tes.sortBufferLen)
 	pos := 0
@@ -61,8 +114,14 @@ func (tes *TextSorter) WriteFile(out io.Writer) error {
 
 		if line.Values == nil {
 			// comment
-			if tes.showComments {
-				fmt.Fprint(out, line.RawString)
+			if !wroteHeader {
+				if tes.txt.Header != nil && !tes.txt.headerComment {
+					commentsAfter = append(commentsAfter, line)
+				} else {
+					commentsBefore = append(commentsBefore, line)
+				}
+			} else if tes.showComments {
+				tes.writeLine(out, line)
 			}
 			continue
 		}
@@ -72,53 +131,44 @@ func (tes *TextSorter) WriteFile(out io.Writer) error {
 			if err != nil {
 				return err
 			}
-			if !tes.txt.noHeader {
-				tes.writeHeader(out)
-			}
+			tes.writeHeaderBlock(out, commentsBefore, commentsAfter, line.RawString)
 			wroteHeader = true
 		}
 
-		records[pos] = TextSortRecord{val: line, cols: tes.cols}
+		records[pos] = TextSortRecord{val: line, cols: tes.cols, order: line.LineNum, stable: stable}
 		pos++
+		read++
+		if tes.progress != nil && !tes.progress(read, false) {
+			tes.txt.Close()
+			return errCancelled
+		}
 
 		if pos >= tes.sortBufferLen {
-			sort.Sort(records)
-
-			curTemp, fErr := ioutil.TempFile("", "tabl_sort")
+			curTemp, fErr := tes.writeTempFile(records[:pos])
 			if fErr != nil {
 				return fErr
 			}
-
-			gzTmp := gzip.NewWriter(curTemp)
-
-			for _, rec := range records {
-				tes.writeLine(gzTmp, rec.val)
-			}
-			pos = 0
-
-			gzTmp.Close()
 			files = append(files, curTemp)
+			pos = 0
 		}
 
 	}
 
-	if pos > 0 {
-		sort.Sort(records)
+	// without any rows, the header (and comments) are still written
+	if !wroteHeader && tes.txt.Header != nil {
+		if err := tes.populateColIndex(); err != nil {
+			return err
+		}
+		tes.writeHeaderBlock(out, commentsBefore, commentsAfter, "")
+	}
 
-		curTemp, fErr := ioutil.TempFile("", "tabl_sort")
+	if pos > 0 {
+		curTemp, fErr := tes.writeTempFile(records[:pos])
 		if fErr != nil {
 			return fErr
 		}
-
-		gzTmp := gzip.NewWriter(curTemp)
-
-		for _, rec := range records[:pos] {
-			tes.writeLine(gzTmp, rec.val)
-		}
-		pos = 0
-
-		gzTmp.Close()
 		files = append(files, curTemp)
+		pos = 0
 	}
 
 	tes.txt.Close()
@@ -127,36 +177,82 @@ func (tes *TextSorter) WriteFile(out io.Writer) error {
 
 	// merge the temp files
 
-	sortReaders := make([]*DelimitedTextFile, len(files))
-	sortBuffer := make(TextSortRecords, len(files))
+	readers := make([]RecordReader, len(files))
+	for i, f := range files {
+		readers[i] = &tempFileReader{
+			txt:    tes.txt.Clone(f.Name()).WithNoHeader(true),
+			sorter: tes,
+		}
+	}
+
+	return tes.merge(out, readers, stable, true)
+}
+
+// Merge - merge already sorted readers into a single sorted stream, using the
+// same sort columns (and unique setting) as the sorter. Comments are skipped and
+// rows with equal keys are written in the order of the readers.
+func (tes *TextSorter) Merge(out io.Writer, readers []RecordReader) error {
+	return tes.merge(out, readers, true, false)
+}
+
+// merge - the merge phase of the sort. Ties are broken by the original line number
+// if byLineNum is set (temp files from a single input), or by the reader otherwise.
+func (tes *TextSorter) merge(out io.Writer, readers []RecordReader, stable bool, byLineNum bool) error {
+	sortBuffer := make(TextSortRecords, len(readers))
 
 	validReaders := 0
-	for i, f := range files {
-		sortReaders[i] = tes.txt.Clone(f.Name()).WithNoHeader(true)
-		rec, rErr := sortReaders[i].ReadLine()
-		if rErr != nil {
+	for i, rd := range readers {
+		rec, rErr := readRecord(rd)
+		if rErr != nil && rErr != io.EOF {
 			return rErr
 		}
+		if rec == nil {
+			rd.Close()
+			readers[i] = nil
+			continue
+		}
 
-		sortBuffer[i] = TextSortRecord{val: rec, cols: tes.cols, idx: i}
+		sortBuffer[i] = tes.newMergeRecord(rec, i, stable, byLineNum)
 		validReaders++
 	}
 
+	var last *TextRecord
+	written := 0
+
 	for validReaders > 0 {
 		// fmt.Printf("Sort Buffer: %v\n", sortBuffer)
 		sort.Sort(sortBuffer)
 		lowest := sortBuffer[0]
 		// fmt.Printf("Lowest: %v, idx:%d\n", lowest.val, lowest.idx)
-		tes.writeLine(out, lowest.val)
-		rec, tErr := sortReaders[lowest.idx].ReadLine()
+		if !tes.unique || last == nil || compareRecords(tes.cols, last, lowest.val) != 0 {
+			if tes.lineNums {
+				tes.writeTempLine(out, lowest.val)
+			} else {
+				tes.writeLine(out, lowest.val)
+			}
+			last = lowest.val
+		}
+		written++
+		if tes.progress != nil && !tes.progress(written, true) {
+			for _, rd := range readers {
+				if rd != nil {
+					rd.Close()
+				}
+			}
+			return errCancelled
+		}
+		rec, tErr := readRecord(readers[lowest.idx])
 
-		if tErr != nil {
+		if tErr != nil && tErr != io.EOF {
+			return tErr
+		}
+		if rec == nil {
 			sortBuffer[0].val = nil
-			sortReaders[lowest.idx].Close()
-			sortReaders[lowest.idx] = nil
+			readers[lowest.idx].Close()
+			readers[lowest.idx] = nil
 			validReaders--
 		} else {
-			sortBuffer[0] = TextSortRecord{val: rec, cols: tes.cols, idx: lowest.idx}
+			sortBuffer[0] = tes.newMergeRecord(rec, lowest.idx, stable, byLineNum)
 		}
 	}
 	// fmt.Printf("tmpFiles: %v, len:%d\n", files, len(files))
@@ -165,6 +261,68 @@ func (tes *TextSorter) WriteFile(out io.Writer) error {
 
 }
 
+func (tes *TextSorter) newMergeRecord(rec *TextRecord, idx int, stable bool, byLineNum bool) TextSortRecord {
+	order := idx
+	if byLineNum {
+		order = rec.LineNum
+	}
+	return TextSortRecord{val: rec, cols: tes.cols, idx: idx, order: order, stable: stable}
+}
+
+// readRecord - read the next data record from a reader, skipping comments. Returns
+// a nil record at the end of the stream.
+func readRecord(rd RecordReader) (*TextRecord, error) {
+	for {
+		rec, err := rd.ReadLine()
+		if rec != nil && rec.Values != nil {
+			return rec, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Check - verify that the file is already sorted by the sort columns. Nothing is
+// written; the returned error includes the line number of the first row out of order.
+func (tes *TextSorter) Check() error {
+	var line *TextRecord
+	var last *TextRecord
+	var err error = nil
+
+	defer tes.txt.Close()
+
+	for err == nil {
+		line, err = tes.txt.ReadLine()
+		if err != nil {
+			break
+		}
+
+		if line.Values == nil {
+			// comment
+			continue
+		}
+
+		if last == nil {
+			err := tes.populateColIndex()
+			if err != nil {
+				return err
+			}
+		} else {
+			c := compareRecords(tes.cols, last, line)
+			if c > 0 || (c == 0 && tes.unique) {
+				return fmt.Errorf("Out of order at line %d: %s", line.LineNum, strings.TrimRight(line.RawString, "\r\n"))
+			}
+		}
+		last = line
+	}
+
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
 func (tes *TextSorter) populateColIndex() error {
 	for _, col := range tes.cols {
 		if col.idx == -1 {
@@ -182,21 +340,161 @@ func (tes *TextSorter) populateColIndex() error {
 	return nil
 }
 
+// writeHeaderBlock - write the leading comments and the header. Comments that came
+// before the header are written first, then the header (commented or not), and then
+// any comments between the header and the first data line. A commented header is
+// always written, even if the other comments aren't shown.
+func (tes *TextSorter) writeHeaderBlock(out io.Writer, commentsBefore []*TextRecord, commentsAfter []*TextRecord, firstLine string) {
+	tes.eol = lineEnding(tes.txt.rawHeaderLine)
+	if tes.eol == "" {
+		tes.eol = lineEnding(firstLine)
+	}
+	if tes.eol == "" && tes.txt.IsCrLf {
+		tes.eol = "\r\n"
+	} else if tes.eol == "" {
+		tes.eol = "\n"
+	}
+
+	// the commented header is always the last comment before the data
+	isHeaderComment := tes.txt.headerComment && !tes.txt.noHeader && tes.txt.lastComment != ""
+
+	if tes.showComments {
+		for i, line := range commentsBefore {
+			if isHeaderComment && i == len(commentsBefore)-1 {
+				break
+			}
+			tes.writeLine(out, line)
+		}
+	}
+
+	if !tes.txt.noHeader {
+		tes.writeHeader(out)
+	}
+
+	if tes.showComments {
+		for _, line := range commentsAfter {
+			tes.writeLine(out, line)
+		}
+	}
+}
+
 func (tes *TextSorter) writeHeader(out io.Writer) {
-	if tes.txt.rawHeaderLine != "" {
-		fmt.Fprint(out, tes.txt.rawHeaderLine)
+	if tes.txt.headerComment && tes.txt.lastComment != "" {
+		tes.writeRaw(out, tes.txt.lastComment)
+	} else if tes.txt.rawHeaderLine != "" {
+		tes.writeRaw(out, tes.txt.rawHeaderLine)
 	}
 }
 
 func (tes *TextSorter) writeLine(out io.Writer, line *TextRecord) {
-	fmt.Fprint(out, line.RawString)
+	tes.writeRaw(out, line.RawString)
+}
+
+// writeRaw - write a raw line, adding a line ending (matching the file) if it is missing
+func (tes *TextSorter) writeRaw(out io.Writer, raw string) {
+	fmt.Fprint(out, raw)
+	if lineEnding(raw) == "" {
+		if tes.eol != "" {
+			fmt.Fprint(out, tes.eol)
+		} else {
+			fmt.Fprint(out, "\n")
+		}
+	}
+}
+
+// lineEnding - returns the line ending ("\r\n" or "\n") for a raw line
+func lineEnding(raw string) string {
+	if strings.HasSuffix(raw, "\r\n") {
+		return "\r\n"
+	} else if strings.HasSuffix(raw, "\n") {
+		return "\n"
+	}
+	return ""
+}
+
+// writeTempFile - sort a chunk of records and write them to a gzip compressed temp file
+func (tes *TextSorter) writeTempFile(records TextSortRecords) (*os.File, error) {
+	sort.Sort(records)
+
+	curTemp, err := ioutil.TempFile("", "tabl_sort")
+	if err != nil {
+		return nil, err
+	}
+
+	gzTmp := gzip.NewWriter(curTemp)
+
+	for _, rec := range records {
+		tes.writeTempLine(gzTmp, rec.val)
+	}
+
+	gzTmp.Close()
+	return curTemp, nil
+}
+
+// writeTempLine - temp lines are prefixed with the original line numbers so
+// that they survive the round trip (and ties can be broken by input order).
+func (tes *TextSorter) writeTempLine(out io.Writer, line *TextRecord) {
+	fmt.Fprintf(out, "%d%c%d%c", line.LineNum, tes.txt.Delim, line.DataLineNum, tes.txt.Delim)
+	tes.writeLine(out, line)
+}
+
+// RecordReader - a source of TextRecords, such as a DelimitedTextFile
+type RecordReader interface {
+	ReadLine() (*TextRecord, error)
+	Close()
+}
+
+// tempFileReader - reads back the sorted temp files written by writeTempFile
+type tempFileReader struct {
+	txt    *DelimitedTextFile
+	sorter *TextSorter
+}
+
+func (rd *tempFileReader) ReadLine() (*TextRecord, error) {
+	return rd.sorter.readTempLine(rd.txt)
+}
+
+func (rd *tempFileReader) Close() {
+	rd.txt.Close()
+}
+
+// readTempLine - read a line written by writeTempLine, restoring the original line numbers
+func (tes *TextSorter) readTempLine(rd *DelimitedTextFile) (*TextRecord, error) {
+	rec, err := rd.ReadLine()
+	if rec == nil {
+		return nil, err
+	}
+	if len(rec.Values) < 2 {
+		return nil, fmt.Errorf("Invalid temp file line: %s", rec.RawString)
+	}
+
+	lineNum, err := strconv.Atoi(rec.Values[0])
+	if err != nil {
+		return nil, err
+	}
+	dataLineNum, err := strconv.Atoi(rec.Values[1])
+	if err != nil {
+		return nil, err
+	}
+
+	prefixLen := len(rec.Values[0]) + len(rec.Values[1]) + 2*utf8.RuneLen(tes.txt.Delim)
+
+	rec.LineNum = lineNum
+	rec.DataLineNum = dataLineNum
+	rec.RawString = rec.RawString[prefixLen:]
+	rec.Values = rec.Values[2:]
+	rec.parent = tes.txt
+
+	return rec, err
 }
 
 // TextSortRecord - wrapper to hold a text record (line) and the sort column definitions
 type TextSortRecord struct {
-	val  *TextRecord
-	cols []*TextColumn
-	idx  int
+	val    *TextRecord
+	cols   []*TextColumn
+	idx    int
+	order  int
+	stable bool
 }
 
 // TextSortRecords - sorting interface?
@@ -213,54 +511,22 @@ func (a TextSortRecords) Less(i, j int) bool {
 		return true
 	}
 
-	for k := 0; k < len(a[0].cols); k++ {
-		col := a[0].cols[k]
-		if col.isNum {
-			one, err1 := strconv.ParseFloat(a[i].val.Values[col.idx], 64)
-			if err1 != nil {
-				return true
-			}
-			two, err2 := strconv.ParseFloat(a[j].val.Values[col.idx], 64)
-			if err2 != nil {
-				return true
-			}
+	c := compareRecords(a[i].cols, a[i].val, a[j].val)
+	if c == 0 && a[i].stable {
+		return a[i].order < a[j].order
+	}
+	return c < 0
+}
 
-			if col.isReverse {
-				if two < one {
-					return true
-				} else if one < two {
-					return false
-				}
-			} else {
-				if one < two {
-					return true
-				} else if two < one {
-					return false
-				}
-			}
-		} else {
-			one := a[i].val.Values[col.idx]
-			two := a[j].val.Values[col.idx]
-
-			if col.isReverse {
-				if two < one {
-					return true
-				} else if one < two {
-					return false
-				}
-			} else {
-				if one < two {
-					return true
-				} else if two < one {
-					return false
-				}
-			}
+// compareRecords - compare two records by the given columns, returning -1, 0, or 1
+func compareRecords(cols []*TextColumn, one *TextRecord, two *TextRecord) int {
+	for _, col := range cols {
+		c := col.compare(col.value(one), col.value(two))
+		if c != 0 {
+			return c
 		}
-
 	}
-
-	return false
-
+	return 0
 }
 
 func cleanUpTemp(files *[]*os.File) {
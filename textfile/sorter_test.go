@@ -0,0 +1,207 @@
+package textfile_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/mbreese/tabl/textfile"
+)
+
+func TestSortStable(t *testing.T) {
+	txt := textfile.NewTabFile("testdata/sort_ties.txt")
+	cols := []*textfile.TextColumn{textfile.NewNamedColumn("key")}
+
+	var buf bytes.Buffer
+	err := textfile.NewTextSorter(txt, cols).
+		WithStable(true).
+		WithSortBufferLen(2).
+		WriteFile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "key\tval\tname\n" +
+		"a\t5\tsecond\n" +
+		"a\t3\tfourth\n" +
+		"a\t2\tseventh\n" +
+		"b\t2\tfirst\n" +
+		"b\t1\tthird\n" +
+		"b\t4\tfifth\n" +
+		"c\t1\tsixth\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected stable sort output:\n%s", buf.String())
+	}
+}
+
+func TestSortUnique(t *testing.T) {
+	txt := textfile.NewTabFile("testdata/sort_ties.txt")
+	cols := []*textfile.TextColumn{textfile.NewNamedColumn("key")}
+
+	var buf bytes.Buffer
+	err := textfile.NewTextSorter(txt, cols).
+		WithUnique(true).
+		WithSortBufferLen(3).
+		WriteFile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "key\tval\tname\n" +
+		"a\t5\tsecond\n" +
+		"b\t2\tfirst\n" +
+		"c\t1\tsixth\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected unique sort output:\n%s", buf.String())
+	}
+}
+
+func TestSortCheck(t *testing.T) {
+	txt := textfile.NewTabFile("testdata/sort_ties.txt")
+	cols := []*textfile.TextColumn{textfile.NewNamedColumn("key")}
+
+	err := textfile.NewTextSorter(txt, cols).Check()
+	if err == nil {
+		t.Fatal("Expected an out of order error")
+	}
+	if !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("Expected the error to report line 4, got: %s", err)
+	}
+
+	txt = textfile.NewTabFile("../examples/iris.txt")
+	cols = []*textfile.TextColumn{textfile.NewNamedColumn("class")}
+
+	err = textfile.NewTextSorter(txt, cols).Check()
+	if err != nil {
+		t.Errorf("Expected a sorted file, got: %s", err)
+	}
+}
+
+func TestSortChrom(t *testing.T) {
+	txt := textfile.NewTabFile("testdata/chrom.bed")
+	cols := []*textfile.TextColumn{
+		textfile.NewNamedColumn("chrom").AsChrom(),
+		textfile.NewNamedColumn("start").AsNumber(),
+	}
+
+	var buf bytes.Buffer
+	err := textfile.NewTextSorter(txt, cols).WriteFile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "chrom\tstart\tend\n" +
+		"chr1\t7\t8\n" +
+		"chr2\t20\t30\n" +
+		"chr2\t100\t200\n" +
+		"chr10\t5\t10\n" +
+		"chrX\t1\t5\n" +
+		"chrM\t3\t9\n" +
+		"chrUn_x\t1\t2\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected chromosome sort output:\n%s", buf.String())
+	}
+}
+
+func TestSortChromOrder(t *testing.T) {
+	order, err := textfile.LoadChromOrder("testdata/chrom.fai")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txt := textfile.NewTabFile("testdata/chrom.bed")
+	cols := []*textfile.TextColumn{
+		textfile.NewNamedColumn("chrom").AsChrom().WithChromOrder(order),
+		textfile.NewNamedColumn("start").AsNumber(),
+	}
+
+	var buf bytes.Buffer
+	err = textfile.NewTextSorter(txt, cols).WriteFile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// chromosomes missing from the order file come last
+	expected := "chrom\tstart\tend\n" +
+		"chrM\t3\t9\n" +
+		"chr2\t20\t30\n" +
+		"chr2\t100\t200\n" +
+		"chr1\t7\t8\n" +
+		"chr10\t5\t10\n" +
+		"chrX\t1\t5\n" +
+		"chrUn_x\t1\t2\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected chromosome sort output:\n%s", buf.String())
+	}
+}
+
+func TestSortGolden(t *testing.T) {
+	tests := []struct {
+		input         string
+		headerComment bool
+		showComments  bool
+		golden        string
+	}{
+		{"sort_structure.txt", false, false, "sort_structure.txt"},
+		{"sort_structure.txt", false, true, "sort_structure_comments.txt"},
+		{"sort_crlf.txt", false, false, "sort_crlf.txt"},
+		{"sort_crlf.txt", false, true, "sort_crlf_comments.txt"},
+		{"sort_header_comment.txt", true, false, "sort_header_comment.txt"},
+		{"sort_header_comment.txt", true, true, "sort_header_comment_comments.txt"},
+	}
+
+	for _, test := range tests {
+		txt := textfile.NewTabFile("testdata/" + test.input).WithHeaderComment(test.headerComment)
+		cols := []*textfile.TextColumn{textfile.NewNamedColumn("name")}
+
+		var buf bytes.Buffer
+		err := textfile.NewTextSorter(txt, cols).
+			WithShowComments(test.showComments).
+			WithSortBufferLen(2).
+			WriteFile(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := ioutil.ReadFile("testdata/golden/" + test.golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != string(expected) {
+			t.Errorf("%s doesn't match golden file %s:\n%q", test.input, test.golden, buf.String())
+		}
+	}
+}
+
+func TestSortHeaderOnly(t *testing.T) {
+	tests := []struct {
+		input        string
+		showComments bool
+		expected     string
+	}{
+		{"sort_header_only.txt", false, "name\tscore\n"},
+		{"sort_header_only.txt", true, "## file comment\nname\tscore\n# after header\n"},
+	}
+
+	for _, test := range tests {
+		txt := textfile.NewTabFile("testdata/" + test.input)
+		cols := []*textfile.TextColumn{textfile.NewNamedColumn("name")}
+
+		var buf bytes.Buffer
+		err := textfile.NewTextSorter(txt, cols).
+			WithShowComments(test.showComments).
+			WriteFile(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("%s (comments: %v): expected %q, got %q", test.input, test.showComments, test.expected, buf.String())
+		}
+	}
+}
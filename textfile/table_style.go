@@ -0,0 +1,186 @@
+package textfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StyleColumn describes a column for drawing table borders
+type StyleColumn struct {
+	Width   int
+	Numeric bool
+	LineNum bool
+}
+
+// TableStyle is used to draw the rows, separators and borders of a table.
+//
+// Each cell given to Row is padded to the column width, plus one extra character
+// that is either a space or the '$' marker for a truncated value. Any of the
+// separator lines can be "" to not write a line.
+type TableStyle interface {
+	// Top - the line before the header
+	Top(cols []StyleColumn) string
+	// HeaderSep - the line between the header and the first row
+	HeaderSep(cols []StyleColumn) string
+	// RowSep - the line between two rows
+	RowSep(cols []StyleColumn) string
+	// Bottom - the line after the last row
+	Bottom(cols []StyleColumn) string
+	// Row - join the cells for a row (or the header)
+	Row(cols []StyleColumn, cells []string) string
+	// Escape - escape any characters in a value that have a special meaning for the style
+	Escape(v string) string
+}
+
+// TableStyleNames is the list of known table styles
+var TableStyleNames = []string{"plain", "box", "grid", "markdown", "rst", "org"}
+
+// NewTableStyle - return the table style with the given name
+func NewTableStyle(name string) (TableStyle, error) {
+	switch name {
+	case "", "plain":
+		return &plainStyle{}, nil
+	case "box":
+		return &gridStyle{
+			top:       [4]string{"┌", "─", "┬", "┐"},
+			headerSep: [4]string{"╞", "═", "╪", "╡"},
+			bottom:    [4]string{"└", "─", "┴", "┘"},
+			vert:      "│",
+		}, nil
+	case "grid":
+		return &gridStyle{
+			top:       [4]string{"+", "-", "+", "+"},
+			headerSep: [4]string{"+", "=", "+", "+"},
+			bottom:    [4]string{"+", "-", "+", "+"},
+			vert:      "|",
+		}, nil
+	case "rst":
+		// reStructuredText grid tables need a line between each row
+		return &gridStyle{
+			top:       [4]string{"+", "-", "+", "+"},
+			headerSep: [4]string{"+", "=", "+", "+"},
+			rowSep:    [4]string{"+", "-", "+", "+"},
+			bottom:    [4]string{"+", "-", "+", "+"},
+			vert:      "|",
+		}, nil
+	case "org":
+		return &orgStyle{gridStyle{
+			headerSep: [4]string{"|", "-", "+", "|"},
+			vert:      "|",
+		}}, nil
+	case "markdown", "md":
+		return &markdownStyle{}, nil
+	}
+	return nil, fmt.Errorf("Unknown table style: %s (valid styles: %s)", name, strings.Join(TableStyleNames, ", "))
+}
+
+// plainStyle is the default tabl view style
+type plainStyle struct{}
+
+func (s *plainStyle) Top(cols []StyleColumn) string    { return "" }
+func (s *plainStyle) RowSep(cols []StyleColumn) string { return "" }
+func (s *plainStyle) Bottom(cols []StyleColumn) string { return "" }
+func (s *plainStyle) Escape(v string) string           { return v }
+
+func (s *plainStyle) HeaderSep(cols []StyleColumn) string {
+	var sb strings.Builder
+	for i, col := range cols {
+		if col.LineNum {
+			sb.WriteString("====")
+			continue
+		}
+		if i > 0 && !cols[i-1].LineNum {
+			sb.WriteString("=+=")
+		}
+		sb.WriteString(strings.Repeat("=", col.Width))
+	}
+	sb.WriteString("=")
+	return sb.String()
+}
+
+func (s *plainStyle) Row(cols []StyleColumn, cells []string) string {
+	var sb strings.Builder
+	for i, cell := range cells {
+		if cols[i].LineNum {
+			// line numbers are written as a "[num] " prefix
+			num := strings.TrimSpace(cell)
+			if num == "" {
+				num = " "
+			}
+			sb.WriteString("[" + num + "] ")
+			continue
+		}
+		if i > 0 && !cols[i-1].LineNum {
+			sb.WriteString("| ")
+		}
+		sb.WriteString(cell)
+	}
+	return sb.String()
+}
+
+// gridStyle is used for tables with (optional) borders around each cell.
+// Each line is given as: left, fill, middle, right (a blank left means no line).
+type gridStyle struct {
+	top       [4]string
+	headerSep [4]string
+	rowSep    [4]string
+	bottom    [4]string
+	vert      string
+}
+
+func (s *gridStyle) Top(cols []StyleColumn) string       { return gridLine(s.top, cols) }
+func (s *gridStyle) HeaderSep(cols []StyleColumn) string { return gridLine(s.headerSep, cols) }
+func (s *gridStyle) RowSep(cols []StyleColumn) string    { return gridLine(s.rowSep, cols) }
+func (s *gridStyle) Bottom(cols []StyleColumn) string    { return gridLine(s.bottom, cols) }
+func (s *gridStyle) Escape(v string) string              { return v }
+
+func (s *gridStyle) Row(cols []StyleColumn, cells []string) string {
+	return s.vert + " " + strings.Join(cells, s.vert+" ") + s.vert
+}
+
+func gridLine(chars [4]string, cols []StyleColumn) string {
+	if chars[0] == "" {
+		return ""
+	}
+	parts := make([]string, len(cols))
+	for i, col := range cols {
+		parts[i] = strings.Repeat(chars[1], col.Width+2)
+	}
+	return chars[0] + strings.Join(parts, chars[2]) + chars[3]
+}
+
+// orgStyle writes emacs org-mode tables (a grid with only a line after the header)
+type orgStyle struct {
+	gridStyle
+}
+
+func (s *orgStyle) Escape(v string) string {
+	return strings.ReplaceAll(v, "|", "\\vert{}")
+}
+
+// markdownStyle writes GitHub flavored markdown tables (numeric columns are right-aligned)
+type markdownStyle struct{}
+
+func (s *markdownStyle) Top(cols []StyleColumn) string    { return "" }
+func (s *markdownStyle) RowSep(cols []StyleColumn) string { return "" }
+func (s *markdownStyle) Bottom(cols []StyleColumn) string { return "" }
+
+func (s *markdownStyle) HeaderSep(cols []StyleColumn) string {
+	parts := make([]string, len(cols))
+	for i, col := range cols {
+		if col.Numeric {
+			parts[i] = strings.Repeat("-", col.Width+1) + ":"
+		} else {
+			parts[i] = strings.Repeat("-", col.Width+2)
+		}
+	}
+	return "|" + strings.Join(parts, "|") + "|"
+}
+
+func (s *markdownStyle) Row(cols []StyleColumn, cells []string) string {
+	return "| " + strings.Join(cells, "| ") + "|"
+}
+
+func (s *markdownStyle) Escape(v string) string {
+	return strings.ReplaceAll(v, "|", "\\|")
+}
@@ -1,13 +1,20 @@
 package textfile
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
 
 // TextColumn - the column to export. Initially, the idx is set to -1 for named columns.
 type TextColumn struct {
-	name      string // the name is only used to then find the index
-	idx       int    // this value is -1 when starting for a named column.
-	isNum     bool   // sort as a number
-	isReverse bool   // sort in reverse
+	name       string         // the name is only used to then find the index
+	idx        int            // this value is -1 when starting for a named column.
+	isNum      bool           // sort as a number
+	isReverse  bool           // sort in reverse
+	isChrom    bool           // sort as a chromosome name
+	chromOrder map[string]int // chromosome ordering (if nil, use chr1..chr22,X,Y,M)
 }
 
 //Name - getter for TextColumn.name
@@ -21,12 +28,18 @@ func (col *TextColumn) String() string {
 		if col.isNum {
 			return fmt.Sprintf("%s,n", col.name)
 		}
+		if col.isChrom {
+			return fmt.Sprintf("%s,c", col.name)
+		}
 		return col.name
 	}
 
 	if col.isNum {
 		return fmt.Sprintf("idx:%d,n", col.idx)
 	}
+	if col.isChrom {
+		return fmt.Sprintf("idx:%d,c", col.idx)
+	}
 	return fmt.Sprintf("idx:%d", col.idx)
 }
 
@@ -51,6 +64,18 @@ func (col *TextColumn) AsReverse() *TextColumn {
 	return col
 }
 
+// AsChrom - sets this column to be processed as a chromosome name (for sorting purposes)
+func (col *TextColumn) AsChrom() *TextColumn {
+	col.isChrom = true
+	return col
+}
+
+// WithChromOrder - set the chromosome ordering for this column (see LoadChromOrder)
+func (col *TextColumn) WithChromOrder(order map[string]int) *TextColumn {
+	col.chromOrder = order
+	return col
+}
+
 // NewIndexColumn - the column to export. For columns defined by index, idx is the column number (0-based).
 func NewIndexColumn(idx int) *TextColumn {
 	return &TextColumn{
@@ -58,3 +83,142 @@ func NewIndexColumn(idx int) *TextColumn {
 		idx:  idx,
 	}
 }
+
+// compare - compare two values for this column, returning -1, 0, or 1. Numeric
+// columns that can't be parsed as numbers sort before those that can.
+func (col *TextColumn) compare(one string, two string) int {
+	ret := 0
+	if col.isChrom {
+		ret = compareChrom(one, two, col.chromOrder)
+	} else if col.isNum {
+		f1, err1 := strconv.ParseFloat(one, 64)
+		f2, err2 := strconv.ParseFloat(two, 64)
+		if err1 == nil && err2 == nil {
+			if f1 < f2 {
+				ret = -1
+			} else if f2 < f1 {
+				ret = 1
+			}
+		} else if err1 == nil {
+			ret = 1
+		} else if err2 == nil {
+			ret = -1
+		} else {
+			ret = compareStrings(one, two)
+		}
+	} else {
+		ret = compareStrings(one, two)
+	}
+
+	if col.isReverse {
+		return -ret
+	}
+	return ret
+}
+
+func compareStrings(one string, two string) int {
+	if one < two {
+		return -1
+	} else if two < one {
+		return 1
+	}
+	return 0
+}
+
+// value - get the value for this column from a record (blank if the record is too short)
+func (col *TextColumn) value(rec *TextRecord) string {
+	if col.idx >= 0 && col.idx < len(rec.Values) {
+		return rec.Values[col.idx]
+	}
+	return ""
+}
+
+// LoadChromOrder - read the chromosome order from a file. The chromosome names are
+// taken from the first column, so this can be a .fai or .genome file (or a plain list).
+func LoadChromOrder(fname string) (map[string]int, error) {
+	txt := NewTabFile(fname).WithNoHeader(true)
+	defer txt.Close()
+
+	order := make(map[string]int)
+	for {
+		line, err := txt.ReadLine()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line == nil {
+			break
+		}
+		if line.Values != nil && len(line.Values) > 0 {
+			if _, ok := order[line.Values[0]]; !ok {
+				order[line.Values[0]] = len(order)
+			}
+		}
+	}
+	return order, nil
+}
+
+// compareChrom - compare two chromosome names. If an order is given, those chromosomes
+// come first (in that order). Everything else is sorted as chr1..chr22, chrX, chrY, chrM,
+// followed by any other names (alphabetically).
+func compareChrom(one string, two string, order map[string]int) int {
+	if order != nil {
+		i1, ok1 := order[one]
+		i2, ok2 := order[two]
+		if ok1 && ok2 {
+			if i1 < i2 {
+				return -1
+			} else if i2 < i1 {
+				return 1
+			}
+			return 0
+		} else if ok1 {
+			return -1
+		} else if ok2 {
+			return 1
+		}
+	}
+
+	g1, n1, s1 := chromRank(one)
+	g2, n2, s2 := chromRank(two)
+
+	if g1 != g2 {
+		if g1 < g2 {
+			return -1
+		}
+		return 1
+	}
+	if n1 != n2 {
+		if n1 < n2 {
+			return -1
+		}
+		return 1
+	}
+	if c := compareStrings(s1, s2); c != 0 {
+		return c
+	}
+	return compareStrings(one, two)
+}
+
+// chromRank - split a chromosome name into a group (0: numbered, 1: X, 2: Y, 3: M, 4: other),
+// the chromosome number, and the name without the "chr" prefix.
+func chromRank(name string) (int, int, string) {
+	s := name
+	if len(s) > 3 && strings.EqualFold(s[:3], "chr") {
+		s = s[3:]
+	}
+
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return 0, n, s
+	}
+
+	switch strings.ToUpper(s) {
+	case "X":
+		return 1, 0, s
+	case "Y":
+		return 2, 0, s
+	case "M", "MT":
+		return 3, 0, s
+	}
+
+	return 4, 0, s
+}
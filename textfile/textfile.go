@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -29,6 +30,8 @@ type DelimitedTextFile struct {
 	bufLen         int
 	next           rune
 	hasNext        bool
+	nextWidth      int
+	offset         int64
 	isEOF          bool
 	curLineNum     int
 	curDataLineNum int
@@ -37,6 +40,9 @@ type DelimitedTextFile struct {
 	headerComment  bool
 	lastComment    string
 	rawHeaderLine  string
+	multi          *MultiTextFile
+	index          *fileIndex
+	lineNums       bool
 }
 
 // TextRecord is a single line/record from a delimited text file
@@ -48,6 +54,7 @@ type TextRecord struct {
 	Flag        bool
 	ByteSize    int
 	parent      *DelimitedTextFile
+	start       checkpoint
 }
 
 // NewDelimitedFile returns an open delimited text file
@@ -79,12 +86,27 @@ func (txt *DelimitedTextFile) Clone(fname string) *DelimitedTextFile {
 		Delim:    txt.Delim,
 		Quote:    txt.Quote,
 		Comment:  txt.Comment,
+		IsCrLf:   txt.IsCrLf,
 		rd:       nil,
 		buf:      make([]byte, defaultBufferSize),
 		Header:   nil,
 	}
 }
 
+// reopen - return a new copy of the file that reads from the start. This only works for
+// files that can be re-read (not stdin).
+func (txt *DelimitedTextFile) reopen() (*DelimitedTextFile, error) {
+	if txt.multi != nil {
+		return txt.multi.reopen()
+	}
+	if txt.Filename == "-" || txt.Filename == "" {
+		return nil, errors.New("stdin can't be re-read")
+	}
+	return txt.Clone(txt.Filename).
+		WithNoHeader(txt.noHeader).
+		WithHeaderComment(txt.headerComment), nil
+}
+
 // WithBufferSize - set the internal read buffer (default 64K)
 func (txt *DelimitedTextFile) WithBufferSize(bufferSize int) *DelimitedTextFile {
 	txt.buf = make([]byte, bufferSize)
@@ -103,6 +125,13 @@ func (txt *DelimitedTextFile) WithHeaderComment(val bool) *DelimitedTextFile {
 	return txt
 }
 
+// withLineNums - the data lines start with the original line numbers (like the sort temp files,
+// see writeTempLine), which are used for the line numbers of the records
+func (txt *DelimitedTextFile) withLineNums(val bool) *DelimitedTextFile {
+	txt.lineNums = val
+	return txt
+}
+
 func (txt *DelimitedTextFile) nextRune() (rune, error) {
 	if !txt.hasNext {
 		err := txt.populateNext()
@@ -112,6 +141,7 @@ func (txt *DelimitedTextFile) nextRune() (rune, error) {
 	}
 
 	ret := txt.next
+	txt.offset += int64(txt.nextWidth)
 	txt.populateNext()
 	return ret, nil
 }
@@ -178,6 +208,7 @@ func (txt *DelimitedTextFile) populateNext() error {
 
 	txt.next = b
 	txt.hasNext = true
+	txt.nextWidth = width
 	txt.pos += width
 
 	// fmt.Printf(" -- next: %s => %c\n", txt.next, txt.next)
@@ -190,6 +221,9 @@ func (txt *DelimitedTextFile) ReadLine() (*TextRecord, error) {
 	// if txt.isEOF {
 	// 	return nil, io.EOF
 	// }
+	if txt.multi != nil {
+		return txt.multi.readLine()
+	}
 	if txt.rd == nil {
 		err := txt.open()
 		if err != nil {
@@ -198,6 +232,8 @@ func (txt *DelimitedTextFile) ReadLine() (*TextRecord, error) {
 	}
 
 	for true {
+		// where this line starts (so that it can be re-read later)
+		start := checkpoint{txt.offset, txt.curLineNum, txt.curDataLineNum}
 
 		var sb strings.Builder
 		var sbRaw strings.Builder
@@ -276,8 +312,8 @@ func (txt *DelimitedTextFile) ReadLine() (*TextRecord, error) {
 		txt.curLineNum++
 
 		if err == io.EOF {
+			txt.isEOF = true
 			if l.Len() > 0 {
-				txt.isEOF = true
 				err = nil
 			} else {
 				return nil, err
@@ -298,6 +334,7 @@ func (txt *DelimitedTextFile) ReadLine() (*TextRecord, error) {
 					Flag:        false,
 					ByteSize:    byteSize,
 					parent:      txt,
+					start:       start,
 				}, err
 			}
 			cols := make([]string, l.Len())
@@ -308,6 +345,17 @@ func (txt *DelimitedTextFile) ReadLine() (*TextRecord, error) {
 				e = e.Next()
 			}
 
+			raw := sbRaw.String()
+			lineNum := txt.curLineNum
+			dataLineNum := txt.curDataLineNum + 1
+			if txt.lineNums && (txt.Header != nil || txt.noHeader || txt.headerComment) {
+				// (this isn't the header line)
+				lineNum, dataLineNum, cols, raw, err = txt.splitLineNums(cols, raw)
+				if err != nil {
+					return nil, err
+				}
+			}
+
 			// This is the first non-comment, non-blank row. Must be the header.
 			//
 			// Note, we don't send the header as a line because we don't always know
@@ -357,15 +405,19 @@ func (txt *DelimitedTextFile) ReadLine() (*TextRecord, error) {
 			}
 
 			txt.curDataLineNum++
+			if txt.index != nil {
+				txt.index.add(start)
+			}
 
 			return &TextRecord{
 				Values:      cols,
-				LineNum:     txt.curLineNum,
-				DataLineNum: txt.curDataLineNum,
-				RawString:   sbRaw.String(),
+				LineNum:     lineNum,
+				DataLineNum: dataLineNum,
+				RawString:   raw,
 				Flag:        false,
 				ByteSize:    byteSize,
 				parent:      txt,
+				start:       start,
 			}, err
 
 		}
@@ -375,8 +427,30 @@ func (txt *DelimitedTextFile) ReadLine() (*TextRecord, error) {
 	return nil, nil
 }
 
+// splitLineNums - split the original line numbers from the start of a line
+func (txt *DelimitedTextFile) splitLineNums(cols []string, raw string) (int, int, []string, string, error) {
+	if len(cols) < 2 {
+		return 0, 0, nil, "", fmt.Errorf("Missing line numbers: %s", raw)
+	}
+	lineNum, err := strconv.Atoi(cols[0])
+	if err != nil {
+		return 0, 0, nil, "", err
+	}
+	dataLineNum, err := strconv.Atoi(cols[1])
+	if err != nil {
+		return 0, 0, nil, "", err
+	}
+	prefixLen := len(cols[0]) + len(cols[1]) + 2*utf8.RuneLen(txt.Delim)
+	return lineNum, dataLineNum, cols[2:], raw[prefixLen:], nil
+}
+
 // Close the file
 func (txt *DelimitedTextFile) Close() {
+	if txt.multi != nil {
+		for _, f := range txt.multi.files[txt.multi.cur:] {
+			f.Close()
+		}
+	}
 	txt.buf = nil
 	if txt.rd != nil {
 		txt.rd.Close()
@@ -403,6 +477,12 @@ func (txt *DelimitedTextFile) Close() {
 
 // open the file, taking into account that the file might be gzip compressed.
 func (txt *DelimitedTextFile) open() error {
+	if txt.index != nil && txt.index.gzip != nil {
+		// starting over (see seek)
+		txt.rd = txt.index.gzip.reader(0)
+		return nil
+	}
+
 	rd := bufread.OpenFile(txt.Filename)
 
 	magic := make([]byte, 2)
@@ -416,6 +496,17 @@ func (txt *DelimitedTextFile) open() error {
 		if magic[0] == 0x1F && magic[1] == 0x8B {
 			// this is gzipped
 			// fmt.Println("Gzip!")
+			if txt.index != nil {
+				// keep a cache, so that we can start reading again from the middle
+				gz, e := gzip.NewReader(rd)
+				if e != nil {
+					rd.Close()
+					return e
+				}
+				txt.index.gzip = newGzipCache(&gzipFileReader{gz, rd})
+				txt.rd = txt.index.gzip.reader(0)
+				return nil
+			}
 			tmp, e := gzip.NewReader(rd)
 			if e != nil {
 				rd.Close()
@@ -498,6 +589,45 @@ func (txt *DelimitedTextFile) splitLine(buf string) []string {
 	return cols
 }
 
+// Takes a list of values and joins them into a line (without a line ending), quoting values if needed.
+func (txt *DelimitedTextFile) joinLine(values []string) string {
+	var sb strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteRune(txt.Delim)
+		}
+		if txt.Quote != 0 {
+			sb.WriteString(txt.quoteValue(v))
+		} else {
+			sb.WriteString(v)
+		}
+	}
+	return sb.String()
+}
+
+// Quotes a value if it contains a delimiter, quote, or new line.
+func (txt *DelimitedTextFile) quoteValue(inp string) string {
+	quote := false
+	if strings.Index(inp, "\r") != -1 {
+		quote = true
+	}
+	if strings.Index(inp, "\n") != -1 {
+		quote = true
+	}
+	if txt.Quote != 0 && strings.Index(inp, string(txt.Quote)) != -1 {
+		quote = true
+	}
+	if strings.Index(inp, string(txt.Delim)) != -1 {
+		quote = true
+	}
+
+	if quote {
+		dblq := []rune{txt.Quote, txt.Quote}
+		return string(txt.Quote) + strings.ReplaceAll(inp, string(txt.Quote), string(dblq)) + string(txt.Quote)
+	}
+	return inp
+}
+
 // GetValue - Fetch a value from a record by column name
 func (rec *TextRecord) GetValue(k string) (string, error) {
 	for i, v := range rec.parent.Header {
@@ -0,0 +1,57 @@
+package textfile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mbreese/tabl/support"
+)
+
+// verticalRecord - format a record as "name | value" lines, with the names aligned. Values that are wider
+// than the width are wrapped onto more lines (width <= 0 to not wrap).
+func verticalRecord(names []string, values []string, width int, words bool) []string {
+	nameWidth := 0
+	for _, name := range names {
+		nameWidth = support.MaxInt(nameWidth, support.StringWidth(name))
+	}
+
+	var lines []string
+	for i, name := range names {
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+
+		parts := []string{v}
+		if width > 0 {
+			parts = support.WrapWidth(v, width, words)
+		}
+
+		for j, part := range parts {
+			if j == 0 {
+				lines = append(lines, support.PadRight(name, nameWidth)+" | "+part)
+			} else {
+				lines = append(lines, support.PadRight("", nameWidth)+" | "+part)
+			}
+		}
+	}
+	return lines
+}
+
+// verticalTitle - the title line for a record, with the '+' lined up with the '|' separators
+func verticalTitle(num int, names []string, lines []string) string {
+	nameWidth := 0
+	for _, name := range names {
+		nameWidth = support.MaxInt(nameWidth, support.StringWidth(name))
+	}
+	lineWidth := 0
+	for _, line := range lines {
+		lineWidth = support.MaxInt(lineWidth, support.StringWidth(line))
+	}
+
+	title := fmt.Sprintf("-[ RECORD %d ]", num)
+	if len(title) <= nameWidth {
+		title += strings.Repeat("-", nameWidth-len(title)+1) + "+"
+	}
+	return title + strings.Repeat("-", support.MaxInt(1, lineWidth-len(title)))
+}
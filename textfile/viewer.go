@@ -4,6 +4,8 @@ import (
 	"container/list"
 	"fmt"
 	"io"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/mbreese/tabl/support"
@@ -21,6 +23,32 @@ type TextViewer struct {
 	maxWidth     int
 	colNames     []string
 	colWidth     []int
+	colNumeric   []bool
+	numWidth     []int
+	numbers      []int
+	notNumbers   []int
+	maxLineNum   int
+	exactWidths  bool
+	repeatHeader int
+	summary      bool
+	commentCount int
+	numFormat    *NumberFormat
+	style        TableStyle
+	lineNumWidth int
+	wrap         bool
+	wrapWords    bool
+	maxRowHeight int
+	fitWidth     int
+	fitShrink    bool
+	fitFold      bool
+	blocks       [][2]int
+	blockStart   int
+	blockEnd     int
+	vertical     bool
+	color        bool
+	zebra        bool
+	highlight    *regexp.Regexp
+	rowCount     int
 	wroteHeader  bool
 }
 
@@ -35,6 +63,22 @@ func NewTextViewer(f *DelimitedTextFile) *TextViewer {
 		maxWidth:     0,
 		colNames:     nil,
 		colWidth:     nil,
+		colNumeric:   nil,
+		numFormat:    NewNumberFormat(),
+		style:        &plainStyle{},
+		wrap:         false,
+		wrapWords:    false,
+		maxRowHeight: 0,
+		fitWidth:     0,
+		fitShrink:    false,
+		fitFold:      false,
+		vertical:     false,
+		color:        false,
+		zebra:        false,
+		highlight:    nil,
+		exactWidths:  false,
+		repeatHeader: 0,
+		summary:      false,
 	}
 }
 
@@ -62,6 +106,99 @@ func (tv *TextViewer) WithMaxWidth(i int) *TextViewer {
 	return tv
 }
 
+// WithNumberFormat - set the format used to show numeric columns
+func (tv *TextViewer) WithNumberFormat(nf *NumberFormat) *TextViewer {
+	tv.numFormat = nf
+	return tv
+}
+
+// WithStyle - set the table style
+func (tv *TextViewer) WithStyle(style TableStyle) *TextViewer {
+	tv.style = style
+	return tv
+}
+
+// WithWrap - set wrapping values that are wider than the column onto more lines (instead of truncating them)
+func (tv *TextViewer) WithWrap(b bool) *TextViewer {
+	tv.wrap = b
+	return tv
+}
+
+// WithWrapWords - set wrapping values at spaces (word boundaries), where possible
+func (tv *TextViewer) WithWrapWords(b bool) *TextViewer {
+	tv.wrapWords = b
+	return tv
+}
+
+// WithMaxRowHeight - set the maximum number of lines for a wrapped row (0 for no limit)
+func (tv *TextViewer) WithMaxRowHeight(i int) *TextViewer {
+	tv.maxRowHeight = i
+	return tv
+}
+
+// WithFitWidth - set the width the table should fit in (0 to not fit the table)
+func (tv *TextViewer) WithFitWidth(i int) *TextViewer {
+	tv.fitWidth = i
+	return tv
+}
+
+// WithShrink - set shrinking the columns (proportionally) so that the table fits the width
+func (tv *TextViewer) WithShrink(b bool) *TextViewer {
+	tv.fitShrink = b
+	return tv
+}
+
+// WithFold - set folding the columns that don't fit the width into more blocks (each block is written as a table)
+func (tv *TextViewer) WithFold(b bool) *TextViewer {
+	tv.fitFold = b
+	return tv
+}
+
+// WithVertical - set showing each record as a block of "name | value" lines
+func (tv *TextViewer) WithVertical(b bool) *TextViewer {
+	tv.vertical = b
+	return tv
+}
+
+// WithColor - set using ANSI colors for the output
+func (tv *TextViewer) WithColor(b bool) *TextViewer {
+	tv.color = b
+	return tv
+}
+
+// WithZebra - set using a background color for every other row (instead of coloring every other column)
+func (tv *TextViewer) WithZebra(b bool) *TextViewer {
+	tv.zebra = b
+	return tv
+}
+
+// WithHighlight - set a regular expression for values to highlight (when using colors)
+func (tv *TextViewer) WithHighlight(re *regexp.Regexp) *TextViewer {
+	tv.highlight = re
+	return tv
+}
+
+// WithExactWidths - set reading the entire file first to find the column widths. Normally the
+// widths are estimated from the first lines of the file (which are kept in memory), so a long value
+// later in the file will be truncated. Reading the file twice takes twice as long, but doesn't use
+// more memory. This only works for files that can be re-opened (not stdin).
+func (tv *TextViewer) WithExactWidths(b bool) *TextViewer {
+	tv.exactWidths = b
+	return tv
+}
+
+// WithRepeatHeader - set writing the header again every N rows (0 to only write it once)
+func (tv *TextViewer) WithRepeatHeader(i int) *TextViewer {
+	tv.repeatHeader = i
+	return tv
+}
+
+// WithSummary - set writing the number of rows, comments and columns after the table
+func (tv *TextViewer) WithSummary(b bool) *TextViewer {
+	tv.summary = b
+	return tv
+}
+
 // WriteFile - format and write a delimited text file to a stream
 func (tv *TextViewer) WriteFile(out io.Writer) {
 	var line *TextRecord
@@ -69,6 +206,22 @@ func (tv *TextViewer) WriteFile(out io.Writer) {
 
 	lines := list.New()
 
+	if tv.exactWidths {
+		// read the entire file first to find the widths
+		if scan, err := tv.txt.reopen(); err == nil {
+			for {
+				line, err := scan.ReadLine()
+				if err != nil {
+					break
+				}
+				if line.Values != nil {
+					tv.measureLine(scan.Header, line)
+				}
+			}
+			scan.Close()
+		}
+	}
+
 	// we will need to auto-determine the column widths
 
 	for i := 0; i < linesForEstimation; i++ {
@@ -81,58 +234,55 @@ func (tv *TextViewer) WriteFile(out io.Writer) {
 		if line.Values == nil {
 			continue
 		}
+		tv.measureLine(tv.txt.Header, line)
+	}
 
-		if tv.colNames == nil {
-			tv.colNames = make([]string, len(tv.txt.Header))
-			copy(tv.colNames, tv.txt.Header)
-			tv.colWidth = make([]int, len(tv.txt.Header))
+	tv.finishWidths(tv.txt.Header)
 
-			for j := 0; j < len(tv.txt.Header); j++ {
-				r := []rune(tv.txt.Header[j] + "   ")
-				tv.colWidth[j] = support.MaxInt(tv.minWidth, tv.colWidth[j], len(r))
-				if tv.maxWidth > 0 {
-					tv.colWidth[j] = support.MinInt(tv.colWidth[j], tv.maxWidth)
-				}
-			}
-		}
-
-		if len(tv.colNames) < len(tv.txt.Header) {
-			tv.colNames = make([]string, len(tv.txt.Header))
-			copy(tv.colNames, tv.txt.Header)
-			newWidths := make([]int, len(tv.txt.Header))
-			copy(newWidths, tv.colWidth)
-			tv.colWidth = newWidths
+	// width for the line numbers, with room for a few more digits past the lines we've read
+	// (unless we've read the entire file)
+	if tv.exactWidths && tv.maxLineNum > 0 {
+		tv.lineNumWidth = len(strconv.Itoa(tv.maxLineNum))
+	} else {
+		tv.lineNumWidth = support.MaxInt(1, len(strconv.Itoa(tv.maxLineNum*10)))
+	}
 
-			for j := 0; j < len(tv.txt.Header); j++ {
-				r := []rune(tv.txt.Header[j] + "   ")
-				tv.colWidth[j] = support.MaxInt(tv.minWidth, tv.colWidth[j], len(r))
-				if tv.maxWidth > 0 {
-					tv.colWidth[j] = support.MinInt(tv.colWidth[j], tv.maxWidth)
-				}
-			}
+	if tv.vertical {
+		for e := lines.Front(); e != nil; e = e.Next() {
+			line, _ = e.Value.(*TextRecord)
+			tv.writeVertical(out, line)
 		}
-
-		if tv.colWidth == nil {
-			tv.colWidth = make([]int, len(line.Values))
-			for j := 0; j < len(tv.colWidth); j++ {
-				tv.colWidth[j] = 0
+		for err == nil {
+			line, err = tv.txt.ReadLine()
+			if err != nil {
+				break
 			}
+			tv.writeVertical(out, line)
 		}
-		if len(tv.colWidth) < len(line.Values) {
-			newWidths := make([]int, len(line.Values))
-			for j := 0; j < len(newWidths); j++ {
-				newWidths[j] = 0
-			}
-			copy(newWidths, tv.colWidth)
-			tv.colWidth = newWidths
-		}
-		for j, v := range line.Values {
-			r := []rune(v)
-			tv.colWidth[j] = support.MaxInt(tv.minWidth, tv.colWidth[j], len(r))
-			if tv.maxWidth > 0 {
-				tv.colWidth[j] = support.MinInt(tv.colWidth[j], tv.maxWidth)
+		tv.writeSummary(out)
+		tv.txt.Close()
+		return
+	}
+
+	tv.fitColumns()
+
+	if len(tv.blocks) > 1 {
+		// the columns are folded, so we need to write the lines in chunks (once for each block)
+		tv.writeBlocks(out, lines)
+		for err == nil {
+			chunk := list.New()
+			for i := 0; i < linesForEstimation; i++ {
+				line, err = tv.txt.ReadLine()
+				if err != nil {
+					break
+				}
+				chunk.PushBack(line)
 			}
+			tv.writeBlocks(out, chunk)
 		}
+		tv.writeSummary(out)
+		tv.txt.Close()
+		return
 	}
 
 	e := lines.Front()
@@ -151,77 +301,538 @@ func (tv *TextViewer) WriteFile(out io.Writer) {
 		tv.writeLine(out, line)
 	}
 
+	tv.writeFooter(out)
+	tv.writeSummary(out)
 	tv.txt.Close()
 }
 
-func (tv *TextViewer) writeHeader(out io.Writer) {
-	for i, v := range tv.txt.Header {
-		if i > 0 {
-			fmt.Fprint(out, "| ")
+// growColumns - make sure there is room for n columns
+func (tv *TextViewer) growColumns(n int) {
+	if len(tv.colWidth) >= n {
+		return
+	}
+	grow := func(vals []int) []int {
+		newVals := make([]int, n)
+		copy(newVals, vals)
+		return newVals
+	}
+	tv.colWidth = grow(tv.colWidth)
+	tv.numWidth = grow(tv.numWidth)
+	tv.numbers = grow(tv.numbers)
+	tv.notNumbers = grow(tv.notNumbers)
+}
+
+// measureLine - update the column widths (and which columns are numeric) for a line
+func (tv *TextViewer) measureLine(header []string, line *TextRecord) {
+	if len(tv.colNames) < len(header) {
+		tv.colNames = make([]string, len(header))
+		copy(tv.colNames, header)
+		tv.growColumns(len(header))
+
+		for j := 0; j < len(header); j++ {
+			tv.colWidth[j] = support.MaxInt(tv.minWidth, tv.colWidth[j], support.StringWidth(tv.style.Escape(header[j])+"   "))
+			if tv.maxWidth > 0 {
+				tv.colWidth[j] = support.MinInt(tv.colWidth[j], tv.maxWidth)
+			}
+		}
+	}
+
+	tv.growColumns(len(line.Values))
+	for j, v := range line.Values {
+		tv.colWidth[j] = support.MaxInt(tv.minWidth, tv.colWidth[j], support.StringWidth(tv.style.Escape(v)))
+		if tv.maxWidth > 0 {
+			tv.colWidth[j] = support.MinInt(tv.colWidth[j], tv.maxWidth)
+		}
+
+		if isMissingValue(v) {
+			tv.numWidth[j] = support.MaxInt(tv.numWidth[j], support.StringWidth(v))
+		} else if isNumeric(v) {
+			tv.numbers[j]++
+			tv.numWidth[j] = support.MaxInt(tv.numWidth[j], support.StringWidth(tv.numFormat.Format(v)))
+		} else {
+			tv.notNumbers[j]++
+		}
+	}
+
+	tv.maxLineNum = support.MaxInt(tv.maxLineNum, line.DataLineNum)
+}
+
+// finishWidths - find the columns where all of the (non-missing) values are numbers.
+// These columns are right-aligned and formatted, so the widths are also updated.
+func (tv *TextViewer) finishWidths(header []string) {
+	tv.colNumeric = make([]bool, len(tv.colWidth))
+
+	for j := range tv.colWidth {
+		if tv.numbers[j] == 0 || tv.notNumbers[j] > 0 {
+			continue
+		}
+		tv.colNumeric[j] = true
+
+		width := support.MaxInt(tv.minWidth, tv.numWidth[j])
+		if j < len(header) {
+			width = support.MaxInt(width, support.StringWidth(header[j]+"   "))
+		}
+		if tv.maxWidth > 0 {
+			width = support.MinInt(width, tv.maxWidth)
+		}
+		tv.colWidth[j] = width
+	}
+}
+
+// fitColumns - shrink the columns and/or fold them into blocks so that the table fits the width
+func (tv *TextViewer) fitColumns() {
+	tv.blocks = nil
+	if tv.fitWidth <= 0 || len(tv.colWidth) == 0 {
+		return
+	}
+
+	if tv.fitFold {
+		start := 0
+		for j := 1; j <= len(tv.colWidth); j++ {
+			if j == len(tv.colWidth) {
+				tv.blocks = append(tv.blocks, [2]int{start, -1})
+			} else if tv.tableWidth(start, j+1) > tv.fitWidth {
+				tv.blocks = append(tv.blocks, [2]int{start, j})
+				start = j
+			}
+		}
+	}
+
+	if tv.fitShrink {
+		floor := make([]int, len(tv.colWidth))
+		for j, w := range tv.colWidth {
+			floor[j] = support.MinInt(w, 4)
 		}
 
-		// fmt.Fprintf(os.Stderr, "[%d] %s", i, v)
-		r := []rune(v)
-		s := fmt.Sprintf("%%-%ds", tv.colWidth[i])
-		if len(r) <= tv.colWidth[i] {
-			fmt.Fprintf(out, s+" ", string(r))
+		if tv.blocks == nil {
+			tv.shrinkColumns(0, len(tv.colWidth), floor)
 		} else {
-			fmt.Fprintf(out, s+"$", string(r[:tv.colWidth[i]]))
+			// when folding, only blocks with a single (very wide) column will need to shrink
+			for _, block := range tv.blocks {
+				end := block[1]
+				if end < 0 {
+					end = len(tv.colWidth)
+				}
+				tv.shrinkColumns(block[0], end, floor)
+			}
 		}
-		//fmtFprintf(out, line.Values[i])
 	}
-	fmt.Fprint(out, "\n")
+}
+
+// shrinkColumns - shrink the columns (proportionally to how much each column can shrink) to fit the width
+func (tv *TextViewer) shrinkColumns(start int, end int, floor []int) {
+	excess := tv.tableWidth(start, end) - tv.fitWidth
+	if excess <= 0 {
+		return
+	}
 
-	for i := 0; i < len(tv.txt.Header); i++ {
-		if i > 0 {
-			fmt.Fprint(out, "=+=")
-		} else if tv.showLineNum {
-			fmt.Fprint(out, "====")
+	slack := 0
+	for j := start; j < end; j++ {
+		slack += tv.colWidth[j] - floor[j]
+	}
+	if slack <= 0 {
+		return
+	}
+
+	orig := make([]int, len(tv.colWidth))
+	copy(orig, tv.colWidth)
+
+	target := support.MaxInt(0, slack-excess)
+	for j := start; j < end; j++ {
+		tv.colWidth[j] = floor[j] + (tv.colWidth[j]-floor[j])*target/slack
+	}
+
+	// rounding down can leave a little extra room, so give it back to the columns that were shrunk the most
+	for tv.tableWidth(start, end) < tv.fitWidth {
+		best := -1
+		for j := start; j < end; j++ {
+			if tv.colWidth[j] < orig[j] && (best == -1 || orig[j]-tv.colWidth[j] > orig[best]-tv.colWidth[best]) {
+				best = j
+			}
 		}
-		for j := 0; j < tv.colWidth[i]; j++ {
-			fmt.Fprint(out, "=")
+		if best == -1 {
+			break
 		}
+		tv.colWidth[best]++
 	}
-	fmt.Fprint(out, "=\n")
+}
+
+// tableWidth - how wide a block of columns is when written with the current style
+func (tv *TextViewer) tableWidth(start int, end int) int {
+	tv.blockStart, tv.blockEnd = start, end
+	defer func() { tv.blockStart, tv.blockEnd = 0, 0 }()
+
+	cols := tv.columns(len(tv.colWidth))
+	cells := make([]string, len(cols))
+	for i, col := range cols {
+		if col.LineNum {
+			cells[i] = strings.Repeat("0", col.Width) + " "
+		} else {
+			cells[i] = strings.Repeat(" ", col.Width+1)
+		}
+	}
+	return support.StringWidth(tv.style.Row(cols, cells))
+}
+
+// writeBlocks - write the lines for each block of (folded) columns
+func (tv *TextViewer) writeBlocks(out io.Writer, lines *list.List) {
+	hasData := false
+	for e := lines.Front(); e != nil; e = e.Next() {
+		line, _ := e.Value.(*TextRecord)
+		if line.Values != nil {
+			hasData = true
+			break
+		}
+	}
+
+	for b, block := range tv.blocks {
+		if b > 0 && !hasData {
+			break
+		}
+
+		tv.blockStart, tv.blockEnd = block[0], block[1]
+		if tv.wroteHeader {
+			fmt.Fprintln(out)
+		}
+		tv.wroteHeader = false
+		tv.rowCount = 0
+
+		for e := lines.Front(); e != nil; e = e.Next() {
+			line, _ := e.Value.(*TextRecord)
+			if line.Values == nil && b > 0 {
+				// only show comments once
+				continue
+			}
+			tv.writeLine(out, line)
+		}
+		tv.writeFooter(out)
+	}
+	tv.blockStart, tv.blockEnd = 0, 0
+}
+
+// columns - the columns to draw (for the style), including the line number column (if shown)
+func (tv *TextViewer) columns(count int) []StyleColumn {
+	var cols []StyleColumn
+	if tv.showLineNum {
+		cols = append(cols, StyleColumn{Width: tv.lineNumWidth, LineNum: true})
+	}
+	if tv.blockEnd > 0 {
+		count = support.MinInt(count, tv.blockEnd)
+	}
+	for i := tv.blockStart; i < count; i++ {
+		col := StyleColumn{}
+		if i < len(tv.colWidth) {
+			col.Width = tv.colWidth[i]
+			col.Numeric = tv.colNumeric[i]
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// cells - format the values for a row (or the header) to fill each column. This returns
+// more than one line of cells if values are wrapped.
+func (tv *TextViewer) cells(cols []StyleColumn, values []string, lineNum string, header bool) [][]string {
+	offset := 0
+	if tv.showLineNum {
+		offset = 1
+	}
+
+	// only the columns for the current block
+	known := len(tv.colWidth) - tv.blockStart
+	if tv.blockStart < len(values) {
+		values = values[tv.blockStart:]
+	} else {
+		values = nil
+	}
+
+	// the value for each column, split into lines if wrapping
+	parts := make([][]string, len(cols))
+	height := 1
+	for i := offset; i < len(cols); i++ {
+		v := ""
+		if i-offset < len(values) {
+			v = values[i-offset]
+		}
+
+		if tv.wrap && !cols[i].Numeric && i-offset < known {
+			parts[i] = support.WrapWidth(tv.style.Escape(v), cols[i].Width, tv.wrapWords)
+		} else {
+			parts[i] = []string{v}
+		}
+		height = support.MaxInt(height, len(parts[i]))
+	}
+	if tv.maxRowHeight > 0 {
+		height = support.MinInt(height, tv.maxRowHeight)
+	}
+
+	// matches are highlighted in the values before they are padded (so the padding can't match)
+	mark := func(i int, v string) string { return v }
+	if tv.color && tv.highlight != nil && !header {
+		mark = func(i int, v string) string {
+			base := tv.rowColor(header)
+			if !tv.zebra && (i-offset)%2 == 1 {
+				base += colorAltCol
+			}
+			return highlightMatches(v, tv.highlight, base)
+		}
+	}
+
+	lines := make([][]string, height)
+	for k := 0; k < height; k++ {
+		cells := make([]string, len(cols))
+		if tv.showLineNum {
+			if k == 0 {
+				cells[0] = support.PadLeft(lineNum, tv.lineNumWidth) + " "
+			} else {
+				cells[0] = support.PadLeft("", tv.lineNumWidth) + " "
+			}
+		}
+
+		for i := offset; i < len(cols); i++ {
+			col := cols[i]
+
+			if i-offset >= known {
+				// past the columns we estimated widths for... just show the value
+				cells[i] = mark(i, tv.style.Escape(parts[i][0])) + " "
+				if k > 0 {
+					cells[i] = " "
+				}
+			} else if len(parts[i]) > 1 {
+				// wrapped value
+				cells[i] = support.PadRight("", col.Width) + " "
+				if k < len(parts[i]) {
+					cells[i] = padMarked(parts[i][k], mark(i, parts[i][k]), col.Width, false) + " "
+				}
+				if k == height-1 && len(parts[i]) > height {
+					// there was more to show than the row height allows
+					cells[i] = padMarked(parts[i][k], mark(i, parts[i][k]), col.Width, false) + "$"
+				}
+			} else if k > 0 {
+				cells[i] = support.PadRight("", col.Width) + " "
+			} else if col.Numeric && header && support.StringWidth(tv.style.Escape(parts[i][0])) <= col.Width {
+				// numeric column names are right-aligned to match the values
+				cells[i] = support.PadLeft(tv.style.Escape(parts[i][0]), col.Width) + " "
+			} else if col.Numeric && !header {
+				cells[i] = tv.formatNumber(parts[i][0], col.Width, func(v string) string { return mark(i, v) })
+			} else if tv.wrap {
+				// already escaped
+				cells[i] = tv.formatValue(parts[i][0], col.Width, func(v string) string { return mark(i, v) })
+			} else {
+				cells[i] = tv.formatValue(tv.style.Escape(parts[i][0]), col.Width, func(v string) string { return mark(i, v) })
+			}
+		}
+		lines[k] = cells
+	}
+	return lines
+}
+
+// rowColor - the color for a whole row (or the header)
+func (tv *TextViewer) rowColor(header bool) string {
+	if !tv.color {
+		return ""
+	} else if header {
+		return colorHeader
+	} else if tv.zebra && tv.rowCount%2 == 1 {
+		return colorZebra
+	}
+	return ""
+}
+
+// colorCells - add colors to the (already padded) cells for a row (matches are already highlighted,
+// see cells)
+func (tv *TextViewer) colorCells(cols []StyleColumn, lines [][]string, header bool) [][]string {
+	if !tv.color || header {
+		return lines
+	}
+
+	base := tv.rowColor(header)
+	for _, cells := range lines {
+		k := 0
+		for i, col := range cols {
+			if col.LineNum {
+				cells[i] = colorize(cells[i], colorLineNum, base)
+				continue
+			}
+
+			if !tv.zebra && k%2 == 1 {
+				// every other column is colored
+				cells[i] = colorize(cells[i], colorAltCol, base)
+			}
+			k++
+		}
+	}
+	return lines
+}
+
+// writeRow - write the lines for a row (or the header)
+func (tv *TextViewer) writeRow(out io.Writer, cols []StyleColumn, lines [][]string, header bool) {
+	base := tv.rowColor(header)
+	for _, cells := range tv.colorCells(cols, lines, header) {
+		if base != "" {
+			fmt.Fprintln(out, base+tv.style.Row(cols, cells)+colorReset)
+		} else {
+			fmt.Fprintln(out, tv.style.Row(cols, cells))
+		}
+	}
+}
+
+// writeSep - write a separator line (if the style has one)
+func writeSep(out io.Writer, sep string) {
+	if sep != "" {
+		fmt.Fprintln(out, sep)
+	}
+}
+
+func (tv *TextViewer) writeHeader(out io.Writer) {
+	cols := tv.columns(len(tv.txt.Header))
+	writeSep(out, tv.style.Top(cols))
+	tv.writeRow(out, cols, tv.cells(cols, tv.txt.Header, "", true), true)
+	writeSep(out, tv.style.HeaderSep(cols))
+}
+
+// writeRepeatHeader - write the header again (between rows)
+func (tv *TextViewer) writeRepeatHeader(out io.Writer) {
+	cols := tv.columns(len(tv.txt.Header))
+	if tv.style.Top(cols) != "" {
+		// bordered styles need a line to close the previous row
+		writeSep(out, tv.style.HeaderSep(cols))
+	}
+	tv.writeRow(out, cols, tv.cells(cols, tv.txt.Header, "", true), true)
+	writeSep(out, tv.style.HeaderSep(cols))
 }
 
 func (tv *TextViewer) writeLine(out io.Writer, line *TextRecord) {
 	if line.Values == nil {
+		tv.commentCount++
 		if !tv.showComments {
 			return
 		}
-		fmt.Fprintln(out, strings.TrimSuffix(strings.TrimSuffix(line.RawString, "\n"), "\r"))
+		comment := strings.TrimSuffix(strings.TrimSuffix(line.RawString, "\n"), "\r")
+		if tv.color {
+			comment = colorComment + comment + colorReset
+		}
+		fmt.Fprintln(out, comment)
 		return
 	}
 
 	if !tv.wroteHeader {
-		if tv.showLineNum {
-			fmt.Fprintf(out, "[ ] ")
-		}
 		tv.writeHeader(out)
 		tv.wroteHeader = true
 	}
 
-	if tv.showLineNum {
-		fmt.Fprintf(out, "[%d] ", line.DataLineNum)
+	cols := tv.columns(support.MaxInt(len(line.Values), len(tv.colWidth)))
+	if tv.repeatHeader > 0 && tv.rowCount > 0 && tv.rowCount%tv.repeatHeader == 0 {
+		tv.writeRepeatHeader(out)
+	} else if tv.rowCount > 0 {
+		writeSep(out, tv.style.RowSep(cols))
+	}
+	tv.writeRow(out, cols, tv.cells(cols, line.Values, strconv.Itoa(line.DataLineNum), false), false)
+	tv.rowCount++
+}
+
+// writeVertical - write a record as a block of "name | value" lines
+func (tv *TextViewer) writeVertical(out io.Writer, line *TextRecord) {
+	if line.Values == nil {
+		tv.commentCount++
+		if tv.showComments {
+			fmt.Fprintln(out, strings.TrimSuffix(strings.TrimSuffix(line.RawString, "\n"), "\r"))
+		}
+		return
 	}
 
+	values := make([]string, len(line.Values))
 	for i, v := range line.Values {
-		if i > 0 {
-			fmt.Fprint(out, "| ")
+		if i < len(tv.colNumeric) && tv.colNumeric[i] {
+			v = tv.numFormat.Format(v)
 		}
+		values[i] = v
+	}
 
-		// fmt.Fprintf(os.Stderr, "[%d] %s", i, v)
-		r := []rune(v)
+	width := tv.maxWidth
+	if tv.fitWidth > 0 {
+		nameWidth := 0
+		for _, name := range tv.txt.Header {
+			nameWidth = support.MaxInt(nameWidth, support.StringWidth(name))
+		}
+		width = support.MaxInt(1, tv.fitWidth-nameWidth-3)
+	}
 
-		s := fmt.Sprintf("%%-%ds", tv.colWidth[i])
-		if len(r) <= tv.colWidth[i] {
-			fmt.Fprintf(out, s+" ", string(r))
-		} else {
-			fmt.Fprintf(out, s+"$", string(r[:tv.colWidth[i]]))
+	lines := verticalRecord(tv.txt.Header, values, width, tv.wrapWords)
+	title := verticalTitle(line.DataLineNum, tv.txt.Header, lines)
+	if tv.color {
+		title = colorLineNum + title + colorReset
+	}
+	fmt.Fprintln(out, title)
+	for _, l := range lines {
+		if tv.color {
+			// color the name and any matches in the value
+			if idx := strings.Index(l, " | "); idx > -1 {
+				l = colorize(l[:idx], colorHeader, "") + " | " + highlightMatches(l[idx+3:], tv.highlight, "")
+			}
 		}
-		//fmtFprintf(out, line.Values[i])
+		fmt.Fprintln(out, l)
+	}
+}
+
+// writeSummary - write the number of rows, comments and columns (if set)
+func (tv *TextViewer) writeSummary(out io.Writer) {
+	if !tv.summary {
+		return
+	}
+
+	summary := fmt.Sprintf("(%s, %s, %s)",
+		plural(tv.txt.curDataLineNum, "row", "rows"),
+		plural(tv.commentCount, "comment", "comments"),
+		plural(support.MaxInt(len(tv.txt.Header), len(tv.colWidth)), "column", "columns"))
+
+	if tv.color {
+		summary = colorComment + summary + colorReset
+	}
+	fmt.Fprintln(out, summary)
+}
+
+// plural - format a count with the singular or plural name
+func plural(count int, one string, many string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, one)
+	}
+	return fmt.Sprintf("%d %s", count, many)
+}
+
+// writeFooter - write the bottom border of the table (if the style has one)
+func (tv *TextViewer) writeFooter(out io.Writer) {
+	if !tv.wroteHeader {
+		return
 	}
+	writeSep(out, tv.style.Bottom(tv.columns(len(tv.colWidth))))
+}
 
-	fmt.Fprint(out, "\n")
+// formatValue - pad a value to fill the column (by display width), or truncate it and add a '$' marker.
+// The value that is shown is passed to mark (to highlight matches) before it is padded.
+func (tv *TextViewer) formatValue(v string, width int, mark func(string) string) string {
+	if support.StringWidth(v) <= width {
+		return padMarked(v, mark(v), width, false) + " "
+	}
+	v = support.TruncateWidth(v, width)
+	return padMarked(v, mark(v), width, false) + "$"
+}
+
+// formatNumber - format a value from a numeric column and right-align it in the column
+func (tv *TextViewer) formatNumber(v string, width int, mark func(string) string) string {
+	v = tv.numFormat.Format(v)
+	if support.StringWidth(v) <= width {
+		return padMarked(v, mark(v), width, true) + " "
+	}
+	return mark(support.TruncateWidth(v, width)) + "$"
+}
+
+// padMarked - pad a value to fill w terminal cells (on the right, or the left). The value is shown
+// as marked (which can have color codes, so the width comes from the value).
+func padMarked(v string, marked string, w int, left bool) string {
+	pad := strings.Repeat(" ", support.MaxInt(0, w-support.StringWidth(v)))
+	if left {
+		return pad + marked
+	}
+	return marked + pad
 }
@@ -0,0 +1,309 @@
+package textfile_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mbreese/tabl/support"
+	"github.com/mbreese/tabl/textfile"
+)
+
+// separatorCols returns the display column of each '|' in a line
+func separatorCols(line string) []int {
+	var cols []int
+	width := 0
+	for _, r := range line {
+		if r == '|' {
+			cols = append(cols, width)
+		}
+		width += support.StringWidth(string(r))
+	}
+	return cols
+}
+
+func TestViewUnicodeAlignment(t *testing.T) {
+	var buf bytes.Buffer
+	textfile.NewTextViewer(textfile.NewTabFile("testdata/unicode.txt")).WriteFile(&buf)
+
+	// (newer emoji need a current width table, or they are measured as 0 cells)
+	for _, emoji := range []string{"😀", "🧬"} {
+		if w := support.StringWidth(emoji); w != 2 {
+			t.Errorf("Expected %s to be 2 cells wide, got %d", emoji, w)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("Expected 7 lines, got %d:\n%s", len(lines), buf.String())
+	}
+
+	expected := separatorCols(lines[0])
+	if len(expected) != 2 {
+		t.Fatalf("Expected 2 column separators in the header: %s", lines[0])
+	}
+	for _, line := range lines[2:] {
+		cols := separatorCols(line)
+		if len(cols) != len(expected) || cols[0] != expected[0] || cols[1] != expected[1] {
+			t.Errorf("Misaligned columns %v (expected %v): %s", cols, expected, line)
+		}
+	}
+}
+
+func TestViewUnicodeTruncate(t *testing.T) {
+	var buf bytes.Buffer
+	textfile.NewTextViewer(textfile.NewTabFile("testdata/unicode.txt")).WithMaxWidth(5).WriteFile(&buf)
+
+	lines := strings.Split(buf.String(), "\n")
+
+	// 李小龍 is 6 cells wide, so only two characters fit (plus a space to fill the column)
+	if !strings.HasPrefix(lines[3], "李小 $| Seoul$| 😀 pa$") {
+		t.Errorf("Unexpected truncated line: %q", lines[3])
+	}
+	if !strings.HasPrefix(lines[4], "Jose\u0301  | Züric$| café ") {
+		t.Errorf("Unexpected truncated line: %q", lines[4])
+	}
+}
+
+func TestViewNumericAlignment(t *testing.T) {
+	var buf bytes.Buffer
+	textfile.NewTextViewer(textfile.NewTabFile("testdata/numbers.txt")).
+		WithNumberFormat(textfile.NewNumberFormat().WithThousands(true)).
+		WriteFile(&buf)
+
+	expected := "name    |    count |    value \n" +
+		"========+==========+==========\n" +
+		"a       |    1,234 |     0.05 \n" +
+		"b       |       12 |       NA \n" +
+		"c       | 1,000.25 |   -1e-05 \n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected view output:\n%s", buf.String())
+	}
+}
+
+func TestViewStyleGolden(t *testing.T) {
+	for _, name := range textfile.TableStyleNames {
+		style, err := textfile.NewTableStyle(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		textfile.NewTextViewer(textfile.NewTabFile("testdata/view_style.txt")).WithStyle(style).WriteFile(&buf)
+
+		expected, err := ioutil.ReadFile("testdata/golden/view_" + name + ".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != string(expected) {
+			t.Errorf("Style %s doesn't match golden file:\n%s", name, buf.String())
+		}
+	}
+
+	if _, err := textfile.NewTableStyle("foo"); err == nil {
+		t.Error("Expected an error for an unknown style")
+	}
+}
+
+func TestViewWrap(t *testing.T) {
+	var buf bytes.Buffer
+	textfile.NewTextViewer(textfile.NewTabFile("testdata/view_wrap.txt")).
+		WithMaxWidth(12).
+		WithWrap(true).
+		WithWrapWords(true).
+		WithMaxRowHeight(3).
+		WriteFile(&buf)
+
+	expected := "   id | desc         |    n \n" +
+		"======+==============+======\n" +
+		"    1 | The quick    |    5 \n" +
+		"      | brown fox    |      \n" +
+		"      | jumps over  $|      \n" +
+		"    2 | short        |    6 \n" +
+		"    3 | Supercalifra |    7 \n" +
+		"      | gilisticexpi |      \n" +
+		"      | alidocious  $|      \n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected wrapped output:\n%s", buf.String())
+	}
+}
+
+func TestViewFit(t *testing.T) {
+	var buf bytes.Buffer
+	textfile.NewTextViewer(textfile.NewTabFile("testdata/view_style.txt")).
+		WithFitWidth(30).
+		WithShrink(true).
+		WriteFile(&buf)
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if w := support.StringWidth(line); w > 30 {
+			t.Errorf("Line is wider than 30 (%d): %s", w, line)
+		}
+	}
+
+	buf.Reset()
+	textfile.NewTextViewer(textfile.NewTabFile("testdata/view_style.txt")).
+		WithFitWidth(25).
+		WithFold(true).
+		WriteFile(&buf)
+
+	expected := "name    |    val \n" +
+		"========+========\n" +
+		"a       |    1.5 \n" +
+		"bb      |     22 \n" +
+		"\n" +
+		"desc        \n" +
+		"============\n" +
+		"pipe | here \n" +
+		"東京        \n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected folded output:\n%s", buf.String())
+	}
+}
+
+func TestViewVertical(t *testing.T) {
+	var buf bytes.Buffer
+	textfile.NewTextViewer(textfile.NewTabFile("testdata/view_wrap.txt")).
+		WithVertical(true).
+		WithMaxWidth(12).
+		WithWrapWords(true).
+		WriteFile(&buf)
+
+	lines := strings.Split(buf.String(), "\n")
+	expected := []string{
+		"-[ RECORD 1 ]------",
+		"id   | 1",
+		"desc | The quick",
+		"     | brown fox",
+		"     | jumps over",
+		"     | the lazy dog",
+		"n    | 5",
+		"-[ RECORD 2 ]-",
+	}
+	for i, line := range expected {
+		if lines[i] != line {
+			t.Errorf("Unexpected line %d: %q (expected %q)", i+1, lines[i], line)
+		}
+	}
+}
+
+func TestViewColor(t *testing.T) {
+	var plain bytes.Buffer
+	textfile.NewTextViewer(textfile.NewTabFile("testdata/view_wrap.txt")).
+		WithShowLineNum(true).
+		WriteFile(&plain)
+
+	if strings.Contains(plain.String(), "\x1b[") {
+		t.Error("Expected no colors by default")
+	}
+
+	for _, zebra := range []bool{false, true} {
+		var buf bytes.Buffer
+		textfile.NewTextViewer(textfile.NewTabFile("testdata/view_wrap.txt")).
+			WithShowLineNum(true).
+			WithColor(true).
+			WithZebra(zebra).
+			WithHighlight(regexp.MustCompile("o[a-z]")).
+			WriteFile(&buf)
+
+		if !strings.Contains(buf.String(), "\x1b[1;33mow\x1b[0m") {
+			t.Errorf("Expected highlighted matches (zebra: %v):\n%q", zebra, buf.String())
+		}
+
+		// the colors shouldn't change the layout
+		stripped := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(buf.String(), "")
+		if stripped != plain.String() {
+			t.Errorf("Colors changed the output (zebra: %v):\n%s", zebra, stripped)
+		}
+	}
+}
+
+func TestViewHighlightPadding(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		numbers  bool
+		expected int
+	}{
+		// the padding isn't part of the values
+		{"\\s+$", false, 0},
+		{" $", false, 0},
+		{"^ ", true, 0},
+		{"t ", false, 0},
+		// (but spaces in the values are)
+		{"k b", false, 1},
+		{"NA", true, 1},
+		{"12", true, 2},
+	}
+
+	for _, test := range tests {
+		fname := "testdata/view_wrap.txt"
+		if test.numbers {
+			fname = "testdata/numbers.txt"
+		}
+		var buf bytes.Buffer
+		textfile.NewTextViewer(textfile.NewTabFile(fname)).
+			WithColor(true).
+			WithHighlight(regexp.MustCompile(test.pattern)).
+			WriteFile(&buf)
+
+		if count := strings.Count(buf.String(), "\x1b[1;33m"); count != test.expected {
+			t.Errorf("%q: expected %d matches, got %d:\n%q", test.pattern, test.expected, count, buf.String())
+		}
+	}
+}
+
+func TestViewExactWidths(t *testing.T) {
+	f, err := ioutil.TempFile("", "tabl-view-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	// the long value is past the lines used to estimate the widths
+	fmt.Fprintln(f, "id\tdesc")
+	for i := 0; i < 10010; i++ {
+		fmt.Fprintf(f, "%d\tx\n", i)
+	}
+	fmt.Fprintln(f, "10010\ta much longer value")
+	f.Close()
+
+	var buf bytes.Buffer
+	textfile.NewTextViewer(textfile.NewTabFile(f.Name())).WriteFile(&buf)
+	if !strings.HasSuffix(buf.String(), "| a much $\n") {
+		t.Errorf("Expected the last value to be truncated: %q", buf.String()[buf.Len()-30:])
+	}
+
+	buf.Reset()
+	textfile.NewTextViewer(textfile.NewTabFile(f.Name())).WithExactWidths(true).WriteFile(&buf)
+	if !strings.HasSuffix(buf.String(), "| a much longer value \n") {
+		t.Errorf("Expected the last value to fit: %q", buf.String()[buf.Len()-30:])
+	}
+}
+
+func TestViewRepeatHeader(t *testing.T) {
+	var buf bytes.Buffer
+	textfile.NewTextViewer(textfile.NewTabFile("testdata/view_style.txt")).
+		WithRepeatHeader(1).
+		WithSummary(true).
+		WriteFile(&buf)
+
+	expected := "name    |    val | desc        \n" +
+		"========+========+=============\n" +
+		"a       |    1.5 | pipe | here \n" +
+		"name    |    val | desc        \n" +
+		"========+========+=============\n" +
+		"bb      |     22 | 東京        \n" +
+		"(2 rows, 0 comments, 3 columns)\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}